@@ -0,0 +1,249 @@
+// Package activity keeps an append-only log of every change made to saves
+// and the bank, so it can be traced afterwards where an Elestral went.
+package activity
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Kind string
+
+const (
+	KindRename  Kind = "rename"
+	KindExport  Kind = "export"
+	KindImport  Kind = "import"
+	KindMove    Kind = "move"
+	KindAdd     Kind = "add"
+	KindRelease Kind = "release"
+	KindRemove  Kind = "remove"
+	KindGender  Kind = "gender"
+	KindRestore Kind = "restore"
+)
+
+// Kinds lists every kind, in the order the UI offers them as filters.
+var Kinds = []Kind{KindRename, KindExport, KindImport, KindMove, KindAdd, KindRelease, KindRemove, KindGender, KindRestore}
+
+// Subject is the Elestral an entry is about.
+type Subject struct {
+	Hash    string `json:"hash"`
+	Species string `json:"species"`
+	Name    string `json:"name"`
+}
+
+// Entry is one line of the log. Action is how the change was described when
+// it was made ("Export Bo to bank"); one action can log several entries.
+type Entry struct {
+	Time     time.Time `json:"time"`
+	User     string    `json:"user"`
+	Tool     string    `json:"tool"`
+	Kind     Kind      `json:"kind"`
+	Action   string    `json:"action"`
+	SavePath string    `json:"save"`
+	Elestral *Subject  `json:"elestral,omitempty"`
+	From     string    `json:"from,omitempty"`
+	To       string    `json:"to,omitempty"`
+	Detail   string    `json:"detail,omitempty"`
+}
+
+func (entry Entry) String() string {
+	var b strings.Builder
+	b.WriteString(entry.Action)
+	if entry.Elestral != nil && !strings.Contains(entry.Action, entry.Elestral.Name) {
+		fmt.Fprintf(&b, ": %s", entry.Elestral.Name)
+	}
+	switch {
+	case entry.From != "" && entry.To != "":
+		fmt.Fprintf(&b, " (%s -> %s)", entry.From, entry.To)
+	case entry.From != "":
+		fmt.Fprintf(&b, " (from %s)", entry.From)
+	case entry.To != "":
+		fmt.Fprintf(&b, " (to %s)", entry.To)
+	}
+	if entry.Detail != "" {
+		fmt.Fprintf(&b, " %s", entry.Detail)
+	}
+	return b.String()
+}
+
+// Matches reports whether query appears in any of the entry's text, ignoring
+// case. An empty query matches everything.
+func (entry Entry) Matches(query string) bool {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return true
+	}
+	fields := []string{entry.User, entry.Tool, string(entry.Kind), entry.Action, entry.SavePath, entry.From, entry.To, entry.Detail}
+	if entry.Elestral != nil {
+		fields = append(fields, entry.Elestral.Hash, entry.Elestral.Species, entry.Elestral.Name)
+	}
+	for _, field := range fields {
+		if strings.Contains(strings.ToLower(field), query) {
+			return true
+		}
+	}
+	return false
+}
+
+// Log is the activity log file at Path. Entries are only ever appended.
+type Log struct {
+	Path string
+	// Tool says what made the changes, e.g. "app" or "command line".
+	Tool string
+
+	mu sync.Mutex
+}
+
+func New(path, tool string) *Log {
+	return &Log{Path: path, Tool: tool}
+}
+
+// currentUser names who made a change: the account Pandora's Bank runs as.
+func currentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	for _, name := range []string{"USER", "USERNAME"} {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return "unknown"
+}
+
+// Append adds entries to the end of the log, filling in the time, user and
+// tool when they aren't set. Nothing already in the log is rewritten.
+func (l *Log) Append(entries ...Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	who := currentUser()
+	var buf bytes.Buffer
+	for _, entry := range entries {
+		if entry.Time.IsZero() {
+			entry.Time = now
+		}
+		if entry.User == "" {
+			entry.User = who
+		}
+		if entry.Tool == "" {
+			entry.Tool = l.Tool
+		}
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("error encoding activity: %w", err)
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	file, err := os.OpenFile(l.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("error opening activity log: %w", err)
+	}
+	if _, err := file.Write(buf.Bytes()); err != nil {
+		file.Close()
+		return fmt.Errorf("error writing activity log: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("error flushing activity log: %w", err)
+	}
+	return file.Close()
+}
+
+// Read returns every entry in the log, oldest first. Lines that can't be
+// read, like one cut short by a crash, are skipped and counted. A missing log
+// has no entries.
+func (l *Log) Read() ([]Entry, int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	file, err := os.Open(l.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("error opening activity log: %w", err)
+	}
+	defer file.Close()
+
+	var entries []Entry
+	skipped := 0
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			skipped++
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return entries, skipped, fmt.Errorf("error reading activity log: %w", err)
+	}
+	return entries, skipped, nil
+}
+
+// Filter returns the entries of kind (any kind if empty) that match query.
+func Filter(entries []Entry, kind Kind, query string) []Entry {
+	var matched []Entry
+	for _, entry := range entries {
+		if kind != "" && entry.Kind != kind {
+			continue
+		}
+		if entry.Matches(query) {
+			matched = append(matched, entry)
+		}
+	}
+	return matched
+}
+
+// WriteCSV writes entries as CSV with a header row, for a spreadsheet.
+func WriteCSV(w io.Writer, entries []Entry) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"time", "user", "tool", "kind", "action", "save", "hash", "species", "name", "from", "to", "detail"})
+	for _, entry := range entries {
+		var subject Subject
+		if entry.Elestral != nil {
+			subject = *entry.Elestral
+		}
+		writer.Write([]string{
+			entry.Time.Format(time.RFC3339), entry.User, entry.Tool, string(entry.Kind), entry.Action, entry.SavePath,
+			subject.Hash, subject.Species, subject.Name, entry.From, entry.To, entry.Detail,
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteJSONL writes entries one JSON object per line, the same as the log.
+func WriteJSONL(w io.Writer, entries []Entry) error {
+	encoder := json.NewEncoder(w)
+	for _, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package activity
+
+import (
+	"pandorasbank/savedata"
+)
+
+// held is an Elestral and where it is kept. Bank entries are all just "Bank"
+// since their positions shift whenever one is taken out.
+type held struct {
+	elestral *savedata.Elestral
+	place    string
+	area     savedata.Area
+}
+
+// holdings groups the Elestrals in gameSave and bank by ID.Hash, keeping the
+// order the hashes were first seen in. Either may be nil.
+func holdings(gameSave *savedata.GameSave, bank *savedata.Bank, order *[]string, seen map[string]bool) map[string][]held {
+	byHash := map[string][]held{}
+	savedata.Each(gameSave, bank, func(at savedata.Location, e *savedata.Elestral) {
+		if !seen[e.ID.Hash] {
+			seen[e.ID.Hash] = true
+			*order = append(*order, e.ID.Hash)
+		}
+		place := at.String()
+		if at.Area == savedata.InBank {
+			place = "Bank"
+		}
+		byHash[e.ID.Hash] = append(byHash[e.ID.Hash], held{elestral: e, place: place, area: at.Area})
+	})
+	return byHash
+}
+
+func subjectOf(e *savedata.Elestral) *Subject {
+	return &Subject{Hash: e.ID.Hash, Species: e.Species, Name: e.Name}
+}
+
+func genderName(isMale bool) string {
+	if isMale {
+		return "Male"
+	}
+	return "Female"
+}
+
+// Changes works out what happened to each Elestral and the player between
+// the before and after states of a save and bank, as entries for action. An
+// Elestral is followed by its ID.Hash, so moves, renames and transfers show
+// up whichever operation made them.
+func Changes(action, savePath string, beforeSave, afterSave *savedata.GameSave, beforeBank, afterBank *savedata.Bank) []Entry {
+	var entries []Entry
+	add := func(kind Kind, e *savedata.Elestral, from, to, detail string) {
+		entry := Entry{Kind: kind, Action: action, SavePath: savePath, From: from, To: to, Detail: detail}
+		if e != nil {
+			entry.Elestral = subjectOf(e)
+		}
+		entries = append(entries, entry)
+	}
+
+	if beforeSave != nil && afterSave != nil && beforeSave.ActivePlayerData.IsMaleCharacter != afterSave.ActivePlayerData.IsMaleCharacter {
+		add(KindGender, nil, genderName(beforeSave.ActivePlayerData.IsMaleCharacter), genderName(afterSave.ActivePlayerData.IsMaleCharacter), "")
+	}
+
+	var order []string
+	seen := map[string]bool{}
+	before := holdings(beforeSave, beforeBank, &order, seen)
+	after := holdings(afterSave, afterBank, &order, seen)
+
+	for _, hash := range order {
+		was, is := before[hash], after[hash]
+
+		renamed := func(from, to held) {
+			if from.elestral.Name != to.elestral.Name {
+				add(KindRename, to.elestral, from.elestral.Name, to.elestral.Name, "in "+to.place)
+			}
+		}
+
+		// Anything still where it was only needs checking for a new name.
+		var moved []held
+		for _, from := range was {
+			stayed := false
+			for i, to := range is {
+				if to.place == from.place {
+					renamed(from, to)
+					is = append(is[:i:i], is[i+1:]...)
+					stayed = true
+					break
+				}
+			}
+			if !stayed {
+				moved = append(moved, from)
+			}
+		}
+
+		for i, from := range moved {
+			if i >= len(is) {
+				kind := KindRemove
+				if from.area == savedata.InBank {
+					kind = KindRelease
+				}
+				add(kind, from.elestral, from.place, "", "")
+				continue
+			}
+			to := is[i]
+			kind := KindMove
+			switch {
+			case from.area != savedata.InBank && to.area == savedata.InBank:
+				kind = KindExport
+			case from.area == savedata.InBank && to.area != savedata.InBank:
+				kind = KindImport
+			}
+			add(kind, to.elestral, from.place, to.place, "")
+			renamed(from, to)
+		}
+		for i := len(moved); i < len(is); i++ {
+			add(KindAdd, is[i].elestral, "", is[i].place, "")
+		}
+	}
+
+	return entries
+}
+
+// Restored describes a restore of savePath from source, a backup or
+// archive, followed by what it changed. The banks are nil when the bank
+// wasn't restored.
+func Restored(savePath, source string, beforeSave, afterSave *savedata.GameSave, beforeBank, afterBank *savedata.Bank) []Entry {
+	action := "Restore from " + source
+	entries := []Entry{{Kind: KindRestore, Action: action, SavePath: savePath, From: source}}
+	return append(entries, Changes(action, savePath, beforeSave, afterSave, beforeBank, afterBank)...)
+}
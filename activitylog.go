@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"pandorasbank/activity"
+)
+
+func getActivityLogPath() (string, error) {
+	exePath, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	exeDir := filepath.Dir(exePath)
+	return filepath.Join(exeDir, "pbank_activity.jsonl"), nil
+}
+
+// logActivity appends entries to the activity log. The change they describe
+// has already been made, so a failure only warns.
+func logActivity(activityLog *activity.Log, myWindow fyne.Window, entries ...activity.Entry) {
+	if activityLog == nil {
+		return
+	}
+	if err := activityLog.Append(entries...); err != nil {
+		dialog.ShowError(fmt.Errorf("error writing activity log: %w", err), myWindow)
+	}
+}
+
+// showActivityLog opens a window listing the activity log, newest first,
+// with a filter by kind and text and an export of what is shown.
+func showActivityLog(bankWindow *BankWindow) {
+	if bankWindow.refreshActivity != nil {
+		return
+	}
+	activityLog := bankWindow.Activity
+	if activityLog == nil {
+		dialog.ShowInformation("Activity Log", "The activity log could not be located.", bankWindow.Window)
+		return
+	}
+
+	logWindow := fyne.CurrentApp().NewWindow("Activity Log")
+
+	var all, shown []activity.Entry
+	statusLabel := widget.NewLabel("")
+
+	list := widget.NewList(
+		func() int { return len(shown) },
+		func() fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(id widget.ListItemID, object fyne.CanvasObject) {
+			entry := shown[id]
+			lines := []string{
+				fmt.Sprintf("%s  %s  %s by %s (%s)", entry.Time.Local().Format("2006-01-02 15:04:05"), entry.Kind, entry.Action, entry.User, entry.Tool),
+			}
+			var details []string
+			if entry.Elestral != nil {
+				details = append(details, fmt.Sprintf("%s the %s, hash %s", entry.Elestral.Name, entry.Elestral.Species, entry.Elestral.Hash))
+			}
+			switch {
+			case entry.From != "" && entry.To != "":
+				details = append(details, fmt.Sprintf("%s -> %s", entry.From, entry.To))
+			case entry.From != "":
+				details = append(details, "from "+entry.From)
+			case entry.To != "":
+				details = append(details, "to "+entry.To)
+			}
+			if entry.Detail != "" {
+				details = append(details, entry.Detail)
+			}
+			if len(details) > 0 {
+				lines = append(lines, "    "+strings.Join(details, "; "))
+			}
+			lines = append(lines, "    "+entry.SavePath)
+			object.(*widget.Label).SetText(strings.Join(lines, "\n"))
+		},
+	)
+
+	kindOptions := []string{"All"}
+	for _, kind := range activity.Kinds {
+		kindOptions = append(kindOptions, string(kind))
+	}
+	kindSelect := widget.NewSelect(kindOptions, nil)
+	searchEntry := widget.NewEntry()
+	searchEntry.SetPlaceHolder("Filter by name, hash, species, location or save...")
+
+	applyFilter := func() {
+		var kind activity.Kind
+		if kindSelect.Selected != "All" {
+			kind = activity.Kind(kindSelect.Selected)
+		}
+		matched := activity.Filter(all, kind, searchEntry.Text)
+		// Newest first.
+		shown = make([]activity.Entry, len(matched))
+		for i, entry := range matched {
+			shown[len(matched)-1-i] = entry
+		}
+		statusLabel.SetText(fmt.Sprintf("%d of %d entries", len(shown), len(all)))
+		list.Refresh()
+	}
+	reload := func() {
+		entries, skipped, err := activityLog.Read()
+		if err != nil {
+			dialog.ShowError(err, logWindow)
+		}
+		all = entries
+		applyFilter()
+		if skipped > 0 {
+			statusLabel.SetText(fmt.Sprintf("%s (%d unreadable lines skipped)", statusLabel.Text, skipped))
+		}
+	}
+	kindSelect.OnChanged = func(string) { applyFilter() }
+	searchEntry.OnChanged = func(string) { applyFilter() }
+	kindSelect.SetSelected("All")
+
+	exportButton := widget.NewButton("Export...", func() {
+		exportActivity(shown, logWindow)
+	})
+	reloadButton := widget.NewButton("Reload", reload)
+
+	bankWindow.refreshActivity = reload
+	logWindow.SetOnClosed(func() {
+		bankWindow.refreshActivity = nil
+	})
+	reload()
+
+	filters := container.NewBorder(nil, nil, kindSelect, nil, searchEntry)
+	footer := container.NewBorder(nil, nil, statusLabel, container.NewHBox(reloadButton, exportButton))
+	logWindow.SetContent(container.NewBorder(filters, footer, nil, nil, list))
+	logWindow.Resize(fyne.NewSize(800, 600))
+	logWindow.Show()
+}
+
+// exportActivity writes entries as CSV, or as JSON lines when the file name
+// ends in .jsonl.
+func exportActivity(entries []activity.Entry, myWindow fyne.Window) {
+	saveDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, myWindow)
+			return
+		}
+		if writer == nil {
+			return
+		}
+		defer writer.Close()
+
+		if strings.EqualFold(filepath.Ext(writer.URI().Path()), ".jsonl") {
+			err = activity.WriteJSONL(writer, entries)
+		} else {
+			err = activity.WriteCSV(writer, entries)
+		}
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("error exporting activity: %w", err), myWindow)
+			return
+		}
+		dialog.ShowInformation("Export Successful", fmt.Sprintf("Exported %d entries.", len(entries)), myWindow)
+	}, myWindow)
+	saveDialog.SetFileName("pandorasbank_activity.csv")
+	saveDialog.Show()
+}
@@ -0,0 +1,186 @@
+package archive
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"pandorasbank/atomicfile"
+)
+
+// Part identifies what an archived file is, so an import can put it back in
+// the right place on a machine where the paths differ.
+type Part string
+
+const (
+	PartSave     Part = "save"
+	PartBank     Part = "bank"
+	PartSettings Part = "settings"
+)
+
+const (
+	manifestName  = "manifest.json"
+	formatVersion = 1
+)
+
+type File struct {
+	Part         Part   `json:"part"`
+	Name         string `json:"name"`
+	OriginalPath string `json:"originalPath"`
+	SHA256       string `json:"sha256"`
+	Size         int64  `json:"size"`
+}
+
+type Manifest struct {
+	FormatVersion int       `json:"formatVersion"`
+	AppVersion    string    `json:"appVersion"`
+	SaveVersion   string    `json:"saveVersion"`
+	CreatedAt     time.Time `json:"createdAt"`
+	Files         []File    `json:"files"`
+}
+
+func (manifest Manifest) File(part Part) (File, bool) {
+	for _, file := range manifest.Files {
+		if file.Part == part {
+			return file, true
+		}
+	}
+	return File{}, false
+}
+
+// Source is a file to include in an export.
+type Source struct {
+	Part Part
+	Path string
+}
+
+// Archive is an opened export whose files have all been checked against the
+// hashes in its manifest.
+type Archive struct {
+	Manifest Manifest
+	data     map[Part][]byte
+}
+
+func (a *Archive) Data(part Part) ([]byte, bool) {
+	data, ok := a.data[part]
+	return data, ok
+}
+
+func hashOf(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Export writes a zip archive of sources to archivePath. Sources that don't
+// exist are skipped; the manifest's Files and FormatVersion are filled in.
+func Export(archivePath string, manifest Manifest, sources []Source) (Manifest, error) {
+	var buf bytes.Buffer
+	zipWriter := zip.NewWriter(&buf)
+
+	manifest.FormatVersion = formatVersion
+	manifest.Files = nil
+	for _, source := range sources {
+		data, err := os.ReadFile(source.Path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return manifest, fmt.Errorf("error reading %s: %w", source.Part, err)
+		}
+
+		file := File{
+			Part:         source.Part,
+			Name:         string(source.Part) + "/" + filepath.Base(source.Path),
+			OriginalPath: source.Path,
+			SHA256:       hashOf(data),
+			Size:         int64(len(data)),
+		}
+		writer, err := zipWriter.Create(file.Name)
+		if err != nil {
+			return manifest, fmt.Errorf("error adding %s: %w", source.Part, err)
+		}
+		if _, err := writer.Write(data); err != nil {
+			return manifest, fmt.Errorf("error adding %s: %w", source.Part, err)
+		}
+		manifest.Files = append(manifest.Files, file)
+	}
+
+	manifestData, err := json.MarshalIndent(manifest, "", "    ")
+	if err != nil {
+		return manifest, err
+	}
+	writer, err := zipWriter.Create(manifestName)
+	if err != nil {
+		return manifest, fmt.Errorf("error adding manifest: %w", err)
+	}
+	if _, err := writer.Write(manifestData); err != nil {
+		return manifest, fmt.Errorf("error adding manifest: %w", err)
+	}
+	if err := zipWriter.Close(); err != nil {
+		return manifest, fmt.Errorf("error finishing archive: %w", err)
+	}
+
+	if err := atomicfile.WriteFile(archivePath, buf.Bytes(), 0644, func(data []byte) error {
+		_, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		return err
+	}); err != nil {
+		return manifest, err
+	}
+	return manifest, nil
+}
+
+// Open reads an archive made by Export and verifies every file in it.
+func Open(archivePath string) (*Archive, error) {
+	zipReader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("error opening archive: %w", err)
+	}
+	defer zipReader.Close()
+
+	contents := map[string][]byte{}
+	for _, zipFile := range zipReader.File {
+		reader, err := zipFile.Open()
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s from archive: %w", zipFile.Name, err)
+		}
+		data, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s from archive: %w", zipFile.Name, err)
+		}
+		contents[zipFile.Name] = data
+	}
+
+	manifestData, ok := contents[manifestName]
+	if !ok {
+		return nil, fmt.Errorf("archive has no %s, it was not made by Pandora's Bank", manifestName)
+	}
+
+	archive := &Archive{data: map[Part][]byte{}}
+	if err := json.Unmarshal(manifestData, &archive.Manifest); err != nil {
+		return nil, fmt.Errorf("error parsing manifest: %w", err)
+	}
+	if archive.Manifest.FormatVersion > formatVersion {
+		return nil, fmt.Errorf("archive format %d is newer than this version of Pandora's Bank supports", archive.Manifest.FormatVersion)
+	}
+
+	for _, file := range archive.Manifest.Files {
+		data, ok := contents[file.Name]
+		if !ok {
+			return nil, fmt.Errorf("archive is missing %s", file.Name)
+		}
+		if hashOf(data) != file.SHA256 {
+			return nil, fmt.Errorf("%s in the archive is damaged (hash mismatch)", file.Name)
+		}
+		archive.data[file.Part] = data
+	}
+
+	return archive, nil
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"pandorasbank/activity"
+	"pandorasbank/archive"
+	"pandorasbank/atomicfile"
+	"pandorasbank/backup"
+	"pandorasbank/gameproc"
+	"pandorasbank/savedata"
+)
+
+func appVersion() string {
+	if version := fyne.CurrentApp().Metadata().Version; version != "" {
+		return version
+	}
+	return "dev"
+}
+
+// archivePaths returns where each part of an archive lives on this machine.
+func archivePaths(savePath string) map[archive.Part]string {
+	paths := map[archive.Part]string{archive.PartSave: savePath}
+	if bankPath, err := getBankFilePath(); err == nil {
+		paths[archive.PartBank] = bankPath
+	}
+	if settingsPath, err := getSettingsFilePath(); err == nil {
+		paths[archive.PartSettings] = settingsPath
+	}
+	return paths
+}
+
+func exportEverything(savePath string, myWindow fyne.Window) {
+	manifest := archive.Manifest{
+		AppVersion: appVersion(),
+		CreatedAt:  time.Now(),
+	}
+	if gameSave, err := savedata.LoadSave(savePath); err == nil {
+		manifest.SaveVersion = gameSave.SaveVersion
+	}
+
+	var sources []archive.Source
+	for part, path := range archivePaths(savePath) {
+		if path != "" {
+			sources = append(sources, archive.Source{Part: part, Path: path})
+		}
+	}
+
+	saveDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, myWindow)
+			return
+		}
+		if writer == nil {
+			return
+		}
+		destPath := writer.URI().Path()
+		writer.Close()
+
+		manifest, err := archive.Export(destPath, manifest, sources)
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("error exporting: %w", err), myWindow)
+			return
+		}
+
+		dialog.ShowInformation("Export Successful",
+			fmt.Sprintf("Exported %d files to:\n%s", len(manifest.Files), destPath), myWindow)
+	}, myWindow)
+
+	now := time.Now()
+	saveDialog.SetFileName(fmt.Sprintf("pandorasbank_export_%d_%02d_%02d.zip", now.Year(), now.Month(), now.Day()))
+	saveDialog.Show()
+}
+
+func describeArchivePart(a *archive.Archive, part archive.Part) string {
+	data, _ := a.Data(part)
+	switch part {
+	case archive.PartSave:
+		var gameSave savedata.GameSave
+		if err := json.Unmarshal(data, &gameSave); err != nil {
+			return "Invalid save: " + err.Error()
+		}
+		return describeSave(&gameSave)
+	case archive.PartBank:
+		var bank savedata.Bank
+		if err := json.Unmarshal(data, &bank); err != nil {
+			return "Invalid bank: " + err.Error()
+		}
+		return fmt.Sprintf("%d Elestrals", len(bank.Elestrals))
+	}
+	return fmt.Sprintf("%d bytes", len(data))
+}
+
+// localSettings returns the archived settings with the save and game
+// locations of this machine, since the archive's are likely from another PC.
+// If the local settings can't be read the locations are left unset.
+func localSettings(data []byte) ([]byte, error) {
+	var imported Settings
+	if err := json.Unmarshal(data, &imported); err != nil {
+		return nil, fmt.Errorf("error reading archived settings: %w", err)
+	}
+	imported.CustomSavePath = ""
+	imported.CustomGamePath = ""
+	if current, err := loadSettings(); err == nil {
+		imported.CustomSavePath = current.CustomSavePath
+		imported.CustomGamePath = current.CustomGamePath
+	}
+	return json.MarshalIndent(&imported, "", "    ")
+}
+
+// importArchivePart validates one part of an archive and writes it to path.
+// Settings keep this machine's save and game locations unless
+// useArchivePaths is set.
+func importArchivePart(a *archive.Archive, part archive.Part, path string, useArchivePaths bool, backups *backup.Manager) error {
+	data, _ := a.Data(part)
+
+	var verify func([]byte) error
+	switch part {
+	case archive.PartSave:
+		verify = savedata.VerifySave
+		if _, err := os.Stat(path); err == nil {
+			if _, err := backups.Snapshot(path, backup.ReasonRestore); err != nil {
+				return fmt.Errorf("error backing up current save: %w", err)
+			}
+		}
+	case archive.PartBank:
+		verify = savedata.VerifyBank
+	case archive.PartSettings:
+		verify = verifyDecodes[Settings]
+		if !useArchivePaths {
+			var err error
+			if data, err = localSettings(data); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("error creating folder for %s: %w", part, err)
+	}
+	if err := atomicfile.WriteFile(path, data, 0644, verify); err != nil {
+		return fmt.Errorf("error importing %s: %w", part, err)
+	}
+	return nil
+}
+
+// importArchive previews an archive and restores the parts the user picks.
+// onImported is called with the parts that were written.
+func importArchive(savePath string, bankWindow *BankWindow, onImported func(parts []archive.Part)) {
+	myWindow := bankWindow.Window
+	dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, myWindow)
+			return
+		}
+		if reader == nil {
+			return
+		}
+		defer reader.Close()
+
+		archivePath := reader.URI().Path()
+		opened, err := archive.Open(archivePath)
+		if err != nil {
+			dialog.ShowError(err, myWindow)
+			return
+		}
+
+		manifest := opened.Manifest
+		paths := archivePaths(savePath)
+		items := []fyne.CanvasObject{
+			widget.NewLabel(fmt.Sprintf("Exported %s by Pandora's Bank %s\nSave version: %s",
+				manifest.CreatedAt.Local().Format("2006-01-02 15:04"), manifest.AppVersion, savedata.DisplayVersion(manifest.SaveVersion))),
+			widget.NewSeparator(),
+		}
+
+		checks := map[archive.Part]*widget.Check{}
+		pathsCheck := widget.NewCheck("Use the archive's save and game locations", nil)
+		for _, part := range []archive.Part{archive.PartSave, archive.PartBank, archive.PartSettings} {
+			if _, ok := manifest.File(part); !ok {
+				continue
+			}
+
+			dest := paths[part]
+			check := widget.NewCheck(fmt.Sprintf("Restore %s", part), nil)
+			detailLabel := widget.NewLabel(describeArchivePart(opened, part))
+			if dest == "" {
+				check.Disable()
+				detailLabel.SetText(detailLabel.Text + "\nNo location for this on this machine. Set a default save location first.")
+			} else {
+				check.SetChecked(true)
+				detailLabel.SetText(detailLabel.Text + "\nTo: " + dest)
+			}
+			detailLabel.Wrapping = fyne.TextWrapWord
+			checks[part] = check
+			items = append(items, check, detailLabel)
+			if part == archive.PartSettings && dest != "" {
+				check.OnChanged = func(checked bool) {
+					if checked {
+						pathsCheck.Enable()
+					} else {
+						pathsCheck.Disable()
+					}
+				}
+				items = append(items, pathsCheck)
+			}
+		}
+
+		content := container.NewVBox(items...)
+		importDialog := dialog.NewCustomConfirm("Import Archive", "Import", "Cancel", content, func(confirm bool) {
+			if !confirm {
+				return
+			}
+			if check, ok := checks[archive.PartSave]; ok && check.Checked {
+				if err := gameproc.CheckClosed(); err != nil {
+					dialog.ShowError(err, myWindow)
+					return
+				}
+			}
+
+			// onImported reopens the save, which would drop pending edits.
+			confirmDiscardPending(bankWindow, func() {
+				beforeSave, _ := savedata.LoadSave(paths[archive.PartSave])
+				beforeBank, _ := savedata.LoadBank(paths[archive.PartBank])
+
+				var imported []archive.Part
+				for _, part := range []archive.Part{archive.PartSave, archive.PartBank, archive.PartSettings} {
+					check, ok := checks[part]
+					if !ok || !check.Checked {
+						continue
+					}
+					if err := importArchivePart(opened, part, paths[part], pathsCheck.Checked, bankWindow.Backups); err != nil {
+						dialog.ShowError(err, myWindow)
+						break
+					}
+					imported = append(imported, part)
+				}
+
+				if len(imported) > 0 {
+					afterSave, _ := savedata.LoadSave(paths[archive.PartSave])
+					afterBank, _ := savedata.LoadBank(paths[archive.PartBank])
+					logActivity(bankWindow.Activity, myWindow, activity.Restored(paths[archive.PartSave], archivePath, beforeSave, afterSave, beforeBank, afterBank)...)
+
+					dialog.ShowInformation("Import Successful",
+						fmt.Sprintf("Imported %d of the archive's files.", len(imported)), myWindow)
+					if onImported != nil {
+						onImported(imported)
+					}
+				}
+			})
+		}, myWindow)
+		importDialog.Resize(fyne.NewSize(500, 500))
+		importDialog.Show()
+	}, myWindow)
+}
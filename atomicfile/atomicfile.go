@@ -0,0 +1,100 @@
+package atomicfile
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// WriteFile replaces the contents of path with data without ever leaving a
+// partially written file behind. The data goes to a temporary file in the same
+// directory which is fsynced and then renamed over path. The result is read
+// back and, if it doesn't match data or verify rejects it, the previous
+// contents of path are put back and an error is returned.
+//
+// verify may be nil. perm is only used when path doesn't exist yet, otherwise
+// the existing file's permissions are kept.
+func WriteFile(path string, data []byte, perm os.FileMode, verify func(data []byte) error) error {
+	previous, err := os.ReadFile(path)
+	existed := err == nil
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("error reading current %s: %w", filepath.Base(path), err)
+	}
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+
+	if verify != nil {
+		if err := verify(data); err != nil {
+			return fmt.Errorf("refusing to write invalid %s: %w", filepath.Base(path), err)
+		}
+	}
+
+	if err := replace(path, data, perm); err != nil {
+		return err
+	}
+
+	verifyErr := check(path, data, verify)
+	if verifyErr == nil {
+		return nil
+	}
+
+	if existed {
+		err = replace(path, previous, perm)
+	} else {
+		err = os.Remove(path)
+	}
+	if err != nil {
+		return fmt.Errorf("%s failed verification after writing (%v) and could not be rolled back: %w", filepath.Base(path), verifyErr, err)
+	}
+	return fmt.Errorf("%s failed verification after writing and was rolled back: %w", filepath.Base(path), verifyErr)
+}
+
+func check(path string, data []byte, verify func(data []byte) error) error {
+	written, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading back: %w", err)
+	}
+	if !bytes.Equal(written, data) {
+		return fmt.Errorf("contents read back differ from what was written")
+	}
+	if verify != nil {
+		return verify(written)
+	}
+	return nil
+}
+
+func replace(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("error creating temporary file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	// Only does anything when something below failed before the rename.
+	defer os.Remove(tmpPath)
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error writing temporary file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error flushing temporary file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error closing temporary file: %w", err)
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return fmt.Errorf("error setting permissions: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("error replacing %s: %w", filepath.Base(path), err)
+	}
+
+	syncDir(dir)
+	return nil
+}
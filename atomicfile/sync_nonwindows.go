@@ -0,0 +1,19 @@
+//go:build !windows
+package atomicfile
+
+import (
+	"os"
+)
+
+// syncDir makes the rename of a file in dir durable. It's best effort as some
+// filesystems don't support syncing a directory, and the rename has already
+// happened either way.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	defer d.Close()
+
+	d.Sync()
+}
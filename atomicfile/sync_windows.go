@@ -0,0 +1,6 @@
+//go:build windows
+package atomicfile
+
+// Windows has no way to fsync a directory; the rename is already durable
+// once MoveFileEx returns.
+func syncDir(dir string) {}
@@ -0,0 +1,301 @@
+package backup
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"pandorasbank/atomicfile"
+)
+
+type Reason string
+
+const (
+	ReasonSession Reason = "session"
+	ReasonEdit    Reason = "edit"
+	ReasonLaunch  Reason = "launch"
+	ReasonTimer   Reason = "timer"
+	ReasonManual  Reason = "manual"
+	ReasonRestore Reason = "restore"
+)
+
+// Policy decides which snapshots survive a prune. The newest KeepLast
+// snapshots are always kept, along with the newest snapshot of each of the
+// last KeepDaily days and KeepWeekly weeks that have one. Labelled snapshots
+// are never pruned.
+type Policy struct {
+	KeepLast   int `json:"keepLast"`
+	KeepDaily  int `json:"keepDaily"`
+	KeepWeekly int `json:"keepWeekly"`
+}
+
+var DefaultPolicy = Policy{
+	KeepLast:   20,
+	KeepDaily:  7,
+	KeepWeekly: 4,
+}
+
+type Snapshot struct {
+	Path   string
+	Time   time.Time
+	Reason Reason
+	Label  string
+}
+
+const (
+	filePrefix = "gamesave_"
+	fileSuffix = ".json"
+	timeLayout = "2006-01-02_15-04-05.000"
+	labelsFile = "labels.json"
+)
+
+// Manager owns a directory of timestamped save snapshots.
+type Manager struct {
+	Dir    string
+	Policy Policy
+
+	mu sync.Mutex
+}
+
+func NewManager(dir string, policy Policy) *Manager {
+	return &Manager{Dir: dir, Policy: policy}
+}
+
+func (m *Manager) SetPolicy(policy Policy) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Policy = policy
+}
+
+// Snapshot copies savePath into the backup directory and prunes old
+// snapshots. If the newest snapshot already has the same contents it is
+// returned instead of writing a duplicate.
+func (m *Manager) Snapshot(savePath string, reason Reason) (Snapshot, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	data, err := os.ReadFile(savePath)
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("error reading save: %w", err)
+	}
+
+	if err := os.MkdirAll(m.Dir, 0755); err != nil {
+		return Snapshot{}, fmt.Errorf("error creating backup directory: %w", err)
+	}
+
+	snapshots, err := m.list()
+	if err != nil {
+		return Snapshot{}, err
+	}
+	if len(snapshots) > 0 {
+		if latest, err := os.ReadFile(snapshots[0].Path); err == nil && bytes.Equal(latest, data) {
+			return snapshots[0], nil
+		}
+	}
+
+	snapshot := Snapshot{Time: time.Now(), Reason: reason}
+	snapshot.Path = filepath.Join(m.Dir, fileName(snapshot))
+	if err := atomicfile.WriteFile(snapshot.Path, data, 0644, nil); err != nil {
+		return Snapshot{}, fmt.Errorf("error writing backup: %w", err)
+	}
+
+	// A failed prune is retried with the next snapshot.
+	m.prune()
+	return snapshot, nil
+}
+
+// List returns the snapshots in the backup directory, newest first.
+func (m *Manager) List() ([]Snapshot, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.list()
+}
+
+// SetLabel names a snapshot, or clears its name when label is empty.
+func (m *Manager) SetLabel(snapshot Snapshot, label string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	labels, err := m.labels()
+	if err != nil {
+		return err
+	}
+
+	name := filepath.Base(snapshot.Path)
+	if label == "" {
+		delete(labels, name)
+	} else {
+		labels[name] = label
+	}
+	return m.saveLabels(labels)
+}
+
+// Restore snapshots savePath, if it exists, and then replaces it with the
+// file at backupPath. verify, if set, must accept the written data.
+func (m *Manager) Restore(backupPath, savePath string, verify func([]byte) error) error {
+	data, err := os.ReadFile(backupPath)
+	if err != nil {
+		return fmt.Errorf("error reading backup: %w", err)
+	}
+
+	if _, err := os.Stat(savePath); err == nil {
+		if _, err := m.Snapshot(savePath, ReasonRestore); err != nil {
+			return fmt.Errorf("error backing up current save before restoring: %w", err)
+		}
+	}
+
+	return atomicfile.WriteFile(savePath, data, 0644, verify)
+}
+
+// Prune deletes the snapshots the policy doesn't keep and returns them.
+func (m *Manager) Prune() ([]Snapshot, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.prune()
+}
+
+func fileName(snapshot Snapshot) string {
+	return filePrefix + snapshot.Time.Format(timeLayout) + "_" + string(snapshot.Reason) + fileSuffix
+}
+
+func parseFileName(name string) (Snapshot, bool) {
+	if !strings.HasPrefix(name, filePrefix) || !strings.HasSuffix(name, fileSuffix) {
+		return Snapshot{}, false
+	}
+	rest := strings.TrimSuffix(strings.TrimPrefix(name, filePrefix), fileSuffix)
+	if len(rest) < len(timeLayout)+2 || rest[len(timeLayout)] != '_' {
+		return Snapshot{}, false
+	}
+
+	t, err := time.ParseInLocation(timeLayout, rest[:len(timeLayout)], time.Local)
+	if err != nil {
+		return Snapshot{}, false
+	}
+	return Snapshot{Time: t, Reason: Reason(rest[len(timeLayout)+1:])}, true
+}
+
+func (m *Manager) labels() (map[string]string, error) {
+	labels := map[string]string{}
+	data, err := os.ReadFile(filepath.Join(m.Dir, labelsFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return labels, nil
+		}
+		return nil, fmt.Errorf("error reading backup labels: %w", err)
+	}
+	if err := json.Unmarshal(data, &labels); err != nil {
+		return nil, fmt.Errorf("error parsing backup labels: %w", err)
+	}
+	return labels, nil
+}
+
+func (m *Manager) saveLabels(labels map[string]string) error {
+	if err := os.MkdirAll(m.Dir, 0755); err != nil {
+		return fmt.Errorf("error creating backup directory: %w", err)
+	}
+	data, err := json.MarshalIndent(labels, "", "    ")
+	if err != nil {
+		return err
+	}
+	if err := atomicfile.WriteFile(filepath.Join(m.Dir, labelsFile), data, 0644, nil); err != nil {
+		return fmt.Errorf("error writing backup labels: %w", err)
+	}
+	return nil
+}
+
+func (m *Manager) list() ([]Snapshot, error) {
+	labels, err := m.labels()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(m.Dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading backup directory: %w", err)
+	}
+
+	var snapshots []Snapshot
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		snapshot, ok := parseFileName(entry.Name())
+		if !ok {
+			continue
+		}
+		snapshot.Path = filepath.Join(m.Dir, entry.Name())
+		snapshot.Label = labels[entry.Name()]
+		snapshots = append(snapshots, snapshot)
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Time.After(snapshots[j].Time)
+	})
+	return snapshots, nil
+}
+
+func (m *Manager) prune() ([]Snapshot, error) {
+	snapshots, err := m.list()
+	if err != nil {
+		return nil, err
+	}
+
+	keep := m.Policy.keep(snapshots)
+	var pruned []Snapshot
+	for i, snapshot := range snapshots {
+		if keep[i] || snapshot.Label != "" {
+			continue
+		}
+		if err := os.Remove(snapshot.Path); err != nil && !os.IsNotExist(err) {
+			return pruned, fmt.Errorf("error removing old backup: %w", err)
+		}
+		pruned = append(pruned, snapshot)
+	}
+	return pruned, nil
+}
+
+// keep marks which of snapshots (newest first) the policy retains.
+func (policy Policy) keep(snapshots []Snapshot) []bool {
+	keep := make([]bool, len(snapshots))
+	if len(snapshots) > 0 {
+		// Whatever the policy, never delete the snapshot that was just taken.
+		keep[0] = true
+	}
+	for i := 0; i < len(snapshots) && i < policy.KeepLast; i++ {
+		keep[i] = true
+	}
+
+	keepNewestPer := func(limit int, period func(time.Time) string) {
+		seen := map[string]bool{}
+		for i, snapshot := range snapshots {
+			if len(seen) >= limit {
+				return
+			}
+			key := period(snapshot.Time)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			keep[i] = true
+		}
+	}
+
+	keepNewestPer(policy.KeepDaily, func(t time.Time) string {
+		return t.Format("2006-01-02")
+	})
+	keepNewestPer(policy.KeepWeekly, func(t time.Time) string {
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-%02d", year, week)
+	})
+
+	return keep
+}
@@ -0,0 +1,353 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"pandorasbank/activity"
+	"pandorasbank/backup"
+	"pandorasbank/gameproc"
+	"pandorasbank/savedata"
+)
+
+const defaultBackupInterval = 15 * time.Minute
+
+func getBackupDirPath() (string, error) {
+	exePath, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	exeDir := filepath.Dir(exePath)
+	return filepath.Join(exeDir, "pbank_backups"), nil
+}
+
+func backupPolicy(settings *Settings) backup.Policy {
+	if settings.BackupPolicy != nil {
+		return *settings.BackupPolicy
+	}
+	return backup.DefaultPolicy
+}
+
+func backupInterval(settings *Settings) time.Duration {
+	if settings.BackupIntervalMinutes > 0 {
+		return time.Duration(settings.BackupIntervalMinutes) * time.Minute
+	}
+	return defaultBackupInterval
+}
+
+// startBackupTimer snapshots the save returned by savePath every interval
+// until the returned stop func is called. savePath reads the open session, so
+// it is called on the UI thread.
+func startBackupTimer(manager *backup.Manager, interval time.Duration, savePath func() string, onError func(error)) (stop func()) {
+	done := make(chan struct{})
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				var path string
+				fyne.DoAndWait(func() {
+					path = savePath()
+				})
+				if path == "" {
+					continue
+				}
+				if _, err := manager.Snapshot(path, backup.ReasonTimer); err != nil {
+					onError(err)
+				}
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+// updateBackupTimer takes timed snapshots while the game is running, whether
+// it was started from Pandora's Bank or found in the process list. It is
+// called on the UI thread whenever either of those changes.
+func updateBackupTimer(bankWindow *BankWindow, savePath func() string) {
+	running := bankWindow.gameRunning()
+	if running == (bankWindow.stopBackupTimer != nil) {
+		return
+	}
+	if !running {
+		bankWindow.stopBackupTimer()
+		bankWindow.stopBackupTimer = nil
+		return
+	}
+	bankWindow.stopBackupTimer = startBackupTimer(bankWindow.Backups, backupInterval(bankWindow.Settings), savePath, func(err error) {
+		fyne.Do(func() {
+			dialog.ShowError(fmt.Errorf("error taking timed backup: %w", err), bankWindow.Window)
+		})
+	})
+}
+
+func showBackupSettings(settings *Settings, manager *backup.Manager, myWindow fyne.Window) {
+	policy := backupPolicy(settings)
+
+	keepLastEntry := widget.NewEntry()
+	keepLastEntry.SetText(strconv.Itoa(policy.KeepLast))
+	keepDailyEntry := widget.NewEntry()
+	keepDailyEntry.SetText(strconv.Itoa(policy.KeepDaily))
+	keepWeeklyEntry := widget.NewEntry()
+	keepWeeklyEntry.SetText(strconv.Itoa(policy.KeepWeekly))
+	intervalEntry := widget.NewEntry()
+	intervalEntry.SetText(strconv.Itoa(int(backupInterval(settings) / time.Minute)))
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Keep last", keepLastEntry),
+		widget.NewFormItem("Keep daily", keepDailyEntry),
+		widget.NewFormItem("Keep weekly", keepWeeklyEntry),
+		widget.NewFormItem("Minutes between backups while playing", intervalEntry),
+	}
+
+	dialog.ShowForm("Backup Settings", "Save", "Cancel", items, func(save bool) {
+		if !save {
+			return
+		}
+
+		var values []int
+		for _, entry := range []*widget.Entry{keepLastEntry, keepDailyEntry, keepWeeklyEntry, intervalEntry} {
+			value, err := strconv.Atoi(entry.Text)
+			if err != nil || value < 0 {
+				dialog.ShowError(fmt.Errorf("%q is not a valid number", entry.Text), myWindow)
+				return
+			}
+			values = append(values, value)
+		}
+		if values[3] == 0 {
+			dialog.ShowError(fmt.Errorf("minutes between backups must be at least 1"), myWindow)
+			return
+		}
+
+		newPolicy := backup.Policy{KeepLast: values[0], KeepDaily: values[1], KeepWeekly: values[2]}
+		settings.BackupPolicy = &newPolicy
+		settings.BackupIntervalMinutes = values[3]
+		if err := saveSettings(settings); err != nil {
+			dialog.ShowError(fmt.Errorf("error saving settings: %w", err), myWindow)
+			return
+		}
+
+		manager.SetPolicy(newPolicy)
+		if _, err := manager.Prune(); err != nil {
+			dialog.ShowError(err, myWindow)
+		}
+	}, myWindow)
+}
+
+func describeSave(gameSave *savedata.GameSave) string {
+	party := strings.Join(gameSave.PartySpecies(), ", ")
+	if party == "" {
+		party = "(empty)"
+	}
+	return fmt.Sprintf(`Player: %s | Money: %d
+Party: %s
+Scene: %s
+Saved: %s | Version: %s`,
+		gameSave.ActivePlayerData.Name,
+		gameSave.ActivePlayerData.Money,
+		party,
+		gameSave.CurrentSceneName,
+		gameSave.SaveTimestamp,
+		savedata.DisplayVersion(gameSave.SaveVersion),
+	)
+}
+
+// confirmRestore refuses backups that don't parse as a save and asks twice
+// before restoring one from a different save version than the live save.
+// onRestored reopens the open save, so any pending edits are confirmed as
+// discarded first.
+func confirmRestore(bankWindow *BankWindow, backupPath, livePath string, myWindow fyne.Window, onRestored func()) {
+	backupSave, err := savedata.LoadSave(backupPath)
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("this file can't be restored because it is not a valid save:\n%w", err), myWindow)
+		return
+	}
+
+	restore := func() {
+		if err := gameproc.CheckClosed(); err != nil {
+			dialog.ShowError(err, myWindow)
+			return
+		}
+		confirmDiscardPending(bankWindow, func() {
+			liveSave, _ := savedata.LoadSave(livePath)
+			if err := bankWindow.Backups.Restore(backupPath, livePath, savedata.VerifySave); err != nil {
+				dialog.ShowError(err, myWindow)
+				return
+			}
+			logActivity(bankWindow.Activity, myWindow, activity.Restored(livePath, backupPath, liveSave, backupSave, nil, nil)...)
+			dialog.ShowInformation("Restore Successful",
+				"Save file has been restored successfully!", myWindow)
+			if onRestored != nil {
+				onRestored()
+			}
+		})
+	}
+
+	message := fmt.Sprintf("This will overwrite your current save file at:\n%s\n\nwith:\n%s\n\nAre you sure?", livePath, describeSave(backupSave))
+	dialog.ShowConfirm("Confirm Restore", message, func(confirm bool) {
+		if !confirm {
+			return
+		}
+
+		liveSave, err := savedata.LoadSave(livePath)
+		if err != nil || liveSave.SaveVersion == backupSave.SaveVersion {
+			restore()
+			return
+		}
+
+		dialog.ShowConfirm("Different Save Version",
+			fmt.Sprintf("The backup is from save version %s but your current save is version %s.\n\nThe game may not load it correctly. Restore anyway?",
+				savedata.DisplayVersion(backupSave.SaveVersion), savedata.DisplayVersion(liveSave.SaveVersion)),
+			func(confirm bool) {
+				if confirm {
+					restore()
+				}
+			}, myWindow)
+	}, myWindow)
+}
+
+type backupListItem struct {
+	snapshot backup.Snapshot
+	gameSave *savedata.GameSave
+	err      error
+}
+
+func (item backupListItem) title() string {
+	title := fmt.Sprintf("%s (%s)", item.snapshot.Time.Format("2006-01-02 15:04:05"), item.snapshot.Reason)
+	if item.snapshot.Label != "" {
+		title += " - " + item.snapshot.Label
+	}
+	return title
+}
+
+func (item backupListItem) summary() string {
+	if item.err != nil {
+		return "Invalid save: " + item.err.Error()
+	}
+	party := strings.Join(item.gameSave.PartySpecies(), ", ")
+	return fmt.Sprintf("%s | %d money | %s | v%s",
+		item.gameSave.ActivePlayerData.Name, item.gameSave.ActivePlayerData.Money, party, savedata.DisplayVersion(item.gameSave.SaveVersion))
+}
+
+func loadBackupListItems(manager *backup.Manager) ([]backupListItem, error) {
+	snapshots, err := manager.List()
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]backupListItem, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		gameSave, err := savedata.LoadSave(snapshot.Path)
+		items = append(items, backupListItem{snapshot: snapshot, gameSave: gameSave, err: err})
+	}
+	return items, nil
+}
+
+// showBackupBrowser opens a window listing the managed snapshots. onRestored
+// runs after one of them has been restored over livePath. onBrowseElestrals,
+// if set, opens a snapshot to recover individual Elestrals from it.
+func showBackupBrowser(bankWindow *BankWindow, livePath string, onRestored func(), onBrowseElestrals func(path string)) {
+	manager := bankWindow.Backups
+	browser := fyne.CurrentApp().NewWindow("Backups")
+
+	items, err := loadBackupListItems(manager)
+	if err != nil {
+		dialog.ShowError(err, browser)
+	}
+
+	details := container.NewVBox(widget.NewLabel("Select a backup to see its details."))
+
+	var list *widget.List
+	list = widget.NewList(
+		func() int { return len(items) },
+		func() fyne.CanvasObject {
+			title := widget.NewLabel("")
+			title.TextStyle = fyne.TextStyle{Bold: true}
+			return container.NewVBox(title, widget.NewLabel(""))
+		},
+		func(id widget.ListItemID, object fyne.CanvasObject) {
+			labels := object.(*fyne.Container).Objects
+			labels[0].(*widget.Label).SetText(items[id].title())
+			labels[1].(*widget.Label).SetText(items[id].summary())
+		},
+	)
+
+	list.OnSelected = func(id widget.ListItemID) {
+		item := items[id]
+		details.RemoveAll()
+
+		title := widget.NewLabel(item.title())
+		title.TextStyle = fyne.TextStyle{Bold: true}
+		details.Add(title)
+
+		labelEntry := widget.NewEntry()
+		labelEntry.SetPlaceHolder("Label (labelled backups are never deleted)")
+		labelEntry.SetText(item.snapshot.Label)
+		saveLabelButton := widget.NewButton("Save Label", func() {
+			if err := manager.SetLabel(item.snapshot, labelEntry.Text); err != nil {
+				dialog.ShowError(err, browser)
+				return
+			}
+			items[id].snapshot.Label = labelEntry.Text
+			list.RefreshItem(id)
+		})
+		details.Add(container.NewBorder(nil, nil, nil, saveLabelButton, labelEntry))
+
+		if item.err != nil {
+			invalidLabel := widget.NewLabel(fmt.Sprintf("This backup is not a valid save and can't be restored:\n%v", item.err))
+			invalidLabel.Wrapping = fyne.TextWrapWord
+			details.Add(invalidLabel)
+			return
+		}
+
+		details.Add(widget.NewLabel(describeSave(item.gameSave)))
+		details.Add(widget.NewSeparator())
+
+		differences := "Could not read the current save to compare."
+		var diff savedata.SaveDiff
+		if liveSave, err := savedata.LoadSave(livePath); err == nil {
+			diff = savedata.Diff(item.gameSave, liveSave)
+			differences = "Changes in the current save since this backup:\n" + diff.Text()
+		}
+		differencesLabel := widget.NewLabel(differences)
+		differencesLabel.Wrapping = fyne.TextWrapWord
+		details.Add(differencesLabel)
+		if !diff.Empty() {
+			details.Add(widget.NewButton("Open Diff...", func() {
+				showSaveDiff("Since "+item.title(), diff)
+			}))
+		}
+
+		restoreButton := widget.NewButton("Restore This Backup", func() {
+			confirmRestore(bankWindow, item.snapshot.Path, livePath, browser, onRestored)
+		})
+		if onBrowseElestrals == nil {
+			details.Add(restoreButton)
+			return
+		}
+		browseElestralsButton := widget.NewButton("Recover Elestrals...", func() {
+			onBrowseElestrals(item.snapshot.Path)
+		})
+		details.Add(container.NewHBox(restoreButton, browseElestralsButton))
+	}
+
+	split := container.NewHSplit(list, container.NewVScroll(details))
+	split.Offset = 0.45
+	browser.SetContent(split)
+	browser.Resize(fyne.NewSize(900, 600))
+	browser.Show()
+}
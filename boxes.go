@@ -0,0 +1,207 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"pandorasbank/atomicfile"
+	"pandorasbank/savedata"
+)
+
+// boxInfo is what the app keeps about a storage box. The save has no room
+// for it, so it lives in pbank_boxes.json and never goes into the save.
+type boxInfo struct {
+	Name        string `json:"name,omitempty"`
+	Color       string `json:"color,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+func (info boxInfo) empty() bool {
+	return info == boxInfo{}
+}
+
+// boxColors are the colours a box can be given, in the order they are
+// offered.
+var boxColors = []struct {
+	Name string
+	Hex  string
+}{
+	{"Red", "#e53935"},
+	{"Orange", "#fb8c00"},
+	{"Yellow", "#fdd835"},
+	{"Green", "#43a047"},
+	{"Teal", "#00897b"},
+	{"Blue", "#1e88e5"},
+	{"Purple", "#8e24aa"},
+	{"Pink", "#d81b60"},
+	{"Grey", "#757575"},
+}
+
+// saveBoxes is the box info of one save, by box index. Boxes with nothing
+// set aren't in it.
+type saveBoxes map[int]boxInfo
+
+// Title is the name given to box, or "Box N".
+func (boxes saveBoxes) Title(box int) string {
+	if name := boxes[box].Name; name != "" {
+		return name
+	}
+	return fmt.Sprintf("Box %d", box+1)
+}
+
+// StorageName is the name given to box, or "Storage Box N", for messages
+// where "Box N" alone would be unclear.
+func (boxes saveBoxes) StorageName(box int) string {
+	if name := boxes[box].Name; name != "" {
+		return name
+	}
+	return fmt.Sprintf("Storage Box %d", box+1)
+}
+
+// Slot names a storage slot by its box's title.
+func (boxes saveBoxes) Slot(slot savedata.StorageSlot) string {
+	if boxes[slot.Box].Name == "" {
+		return slot.String()
+	}
+	return fmt.Sprintf("%s slot %d", boxes.Title(slot.Box), slot.Entry+1)
+}
+
+// Icon is a swatch of the colour given to box, or nil if it has none.
+func (boxes saveBoxes) Icon(box int) fyne.Resource {
+	for _, c := range boxColors {
+		if c.Name == boxes[box].Color {
+			svg := fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="16" height="16" viewBox="0 0 16 16"><rect x="1" y="1" width="14" height="14" rx="3" fill="%s"/></svg>`, c.Hex)
+			return fyne.NewStaticResource("box-"+c.Name+".svg", []byte(svg))
+		}
+	}
+	return nil
+}
+
+// boxStore is the box info of every save, keyed by the save's absolute path.
+type boxStore struct {
+	path  string
+	saves map[string]saveBoxes
+}
+
+func getBoxStorePath() (string, error) {
+	exePath, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	exeDir := filepath.Dir(exePath)
+	return filepath.Join(exeDir, "pbank_boxes.json"), nil
+}
+
+func loadBoxStore() (*boxStore, error) {
+	store := &boxStore{saves: map[string]saveBoxes{}}
+	storePath, err := getBoxStorePath()
+	if err != nil {
+		return store, err
+	}
+
+	data, err := os.ReadFile(storePath)
+	if errors.Is(err, os.ErrNotExist) {
+		store.path = storePath
+		return store, nil
+	}
+	if err != nil {
+		return store, err
+	}
+	if err := json.Unmarshal(data, &store.saves); err != nil {
+		return store, fmt.Errorf("error parsing %s: %w", filepath.Base(storePath), err)
+	}
+	store.path = storePath
+	return store, nil
+}
+
+func boxStoreKey(savePath string) string {
+	if absPath, err := filepath.Abs(savePath); err == nil {
+		return absPath
+	}
+	return filepath.Clean(savePath)
+}
+
+// forSave returns the box info of the save at savePath. The same map is
+// returned each time, so changes to it show wherever it is used.
+func (store *boxStore) forSave(savePath string) saveBoxes {
+	key := boxStoreKey(savePath)
+	if store.saves[key] == nil {
+		store.saves[key] = saveBoxes{}
+	}
+	return store.saves[key]
+}
+
+func (store *boxStore) save() error {
+	if store.path == "" {
+		return errors.New("the box names file could not be read, so it isn't overwritten")
+	}
+	saves := map[string]saveBoxes{}
+	for key, boxes := range store.saves {
+		if len(boxes) > 0 {
+			saves[key] = boxes
+		}
+	}
+	data, err := json.MarshalIndent(saves, "", "    ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(store.path, data, 0644, verifyDecodes[map[string]saveBoxes])
+}
+
+// editBox asks for the name, colour and description of box and keeps them in
+// store. onSaved redraws whatever shows them.
+func editBox(store *boxStore, boxes saveBoxes, box int, myWindow fyne.Window, onSaved func()) {
+	info := boxes[box]
+
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder(fmt.Sprintf("Box %d", box+1))
+	nameEntry.SetText(info.Name)
+
+	const noColor = "None"
+	colorOptions := []string{noColor}
+	for _, c := range boxColors {
+		colorOptions = append(colorOptions, c.Name)
+	}
+	colorSelect := widget.NewSelect(colorOptions, nil)
+	colorSelect.SetSelected(noColor)
+	if info.Color != "" {
+		colorSelect.SetSelected(info.Color)
+	}
+
+	descriptionEntry := widget.NewMultiLineEntry()
+	descriptionEntry.SetText(info.Description)
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Name", nameEntry),
+		widget.NewFormItem("Colour", colorSelect),
+		widget.NewFormItem("Description", descriptionEntry),
+	}
+	dialog.ShowForm(fmt.Sprintf("Box %d", box+1), "Save", "Cancel", items, func(save bool) {
+		if !save {
+			return
+		}
+		edited := boxInfo{Name: strings.TrimSpace(nameEntry.Text), Description: strings.TrimSpace(descriptionEntry.Text)}
+		if colorSelect.Selected != noColor {
+			edited.Color = colorSelect.Selected
+		}
+		if edited.empty() {
+			delete(boxes, box)
+		} else {
+			boxes[box] = edited
+		}
+		if err := store.save(); err != nil {
+			dialog.ShowError(fmt.Errorf("error saving box names: %w", err), myWindow)
+		}
+		if onSaved != nil {
+			onSaved()
+		}
+	}, myWindow)
+}
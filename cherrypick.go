@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+
+	"pandorasbank/savedata"
+)
+
+func pickBackupElestrals(bankWindow *BankWindow) {
+	openDialog := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, bankWindow.Window)
+			return
+		}
+		if reader == nil {
+			return
+		}
+		defer reader.Close()
+
+		showBackupElestrals(reader.URI().Path(), bankWindow)
+	}, bankWindow.Window)
+
+	if bankWindow.Backups != nil {
+		if backupsURI, err := storage.ListerForURI(storage.NewFileURI(bankWindow.Backups.Dir)); err == nil {
+			openDialog.SetLocation(backupsURI)
+		}
+	}
+	openDialog.Show()
+}
+
+// showBackupElestrals opens backupPath read-only and lets Elestrals from its
+// party and storage boxes be copied into the open save or the bank.
+func showBackupElestrals(backupPath string, bankWindow *BankWindow) {
+	session := bankWindow.Session
+	if session == nil {
+		dialog.ShowInformation("Recover Elestrals", "Open a save first to copy Elestrals into it.", bankWindow.Window)
+		return
+	}
+
+	backupSave, err := savedata.LoadSave(backupPath)
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("this file is not a valid save:\n%w", err), bankWindow.Window)
+		return
+	}
+
+	recoverWindow := fyne.CurrentApp().NewWindow("Elestrals in " + filepath.Base(backupPath))
+	content := container.NewVBox()
+
+	// confirmDuplicate asks before copying an Elestral the open save or bank already has.
+	confirmDuplicate := func(e *savedata.Elestral, onConfirm func()) {
+		locations := savedata.Locations(session.GameSave, session.Bank, e.ID.Hash)
+		if len(locations) == 0 {
+			onConfirm()
+			return
+		}
+		dialog.ShowConfirm("Elestral Already Exists",
+			fmt.Sprintf("%s is already in %s.\n\nCopying it will create a duplicate. Copy anyway?", e.Name, strings.Join(locations, ", ")),
+			func(confirm bool) {
+				if confirm {
+					onConfirm()
+				}
+			}, recoverWindow)
+	}
+
+	var render func()
+
+	createCard := func(e *savedata.Elestral) fyne.CanvasObject {
+		nameLabel := widget.NewLabel(e.Name)
+		nameLabel.TextStyle = fyne.TextStyle{Bold: true}
+
+		copyToBankButton := widget.NewButton("Copy to Bank", func() {
+			confirmDuplicate(e, func() {
+				savedata.CopyToBank(session.Bank, e)
+				session.Commit(fmt.Sprintf("Copy %s from backup to bank", e.Name))
+				dialog.ShowInformation("Copy Successful",
+					fmt.Sprintf("%s has been copied to the bank!", e.Name), recoverWindow)
+				render()
+			})
+		})
+
+		copyToStorageButton := widget.NewButton("Copy to Storage", func() {
+			confirmDuplicate(e, func() {
+				slot, err := savedata.CopyToStorage(session.GameSave, e)
+				if err != nil {
+					dialog.ShowError(err, recoverWindow)
+					return
+				}
+				session.Commit(fmt.Sprintf("Copy %s from backup to Storage Box %d", e.Name, slot.Box+1))
+				dialog.ShowInformation("Copy Successful",
+					fmt.Sprintf("%s has been copied to %s!", e.Name, bankWindow.Boxes.forSave(session.Path).StorageName(slot.Box)), recoverWindow)
+				render()
+			})
+		})
+
+		items := []fyne.CanvasObject{
+			container.NewHBox(nameLabel, copyToBankButton, copyToStorageButton),
+			widget.NewLabel(elestralSummary(e)),
+		}
+		if locations := savedata.Locations(session.GameSave, session.Bank, e.ID.Hash); len(locations) > 0 {
+			warningLabel := widget.NewLabel("Already in " + strings.Join(locations, ", "))
+			warningLabel.Importance = widget.WarningImportance
+			items = append(items, warningLabel)
+		}
+
+		return widget.NewCard("", "", container.NewVBox(items...))
+	}
+
+	addSection := func(title string, elestrals []*savedata.Elestral) {
+		var cards []fyne.CanvasObject
+		for _, e := range elestrals {
+			if !e.Empty() {
+				cards = append(cards, createCard(e))
+			}
+		}
+		if len(cards) == 0 {
+			return
+		}
+
+		headerLabel := widget.NewLabel(title)
+		headerLabel.TextStyle = fyne.TextStyle{Bold: true}
+		content.Add(headerLabel)
+		for _, card := range cards {
+			content.Add(card)
+		}
+	}
+
+	render = func() {
+		content.RemoveAll()
+
+		infoLabel := widget.NewLabel(describeSave(backupSave))
+		infoLabel.Wrapping = fyne.TextWrapWord
+		content.Add(infoLabel)
+
+		addSection("Party", backupSave.ActivePlayerData.Party())
+		for i, box := range backupSave.StorageBoxes {
+			var elestrals []*savedata.Elestral
+			for _, entry := range box.Entries {
+				elestrals = append(elestrals, entry.CharacterData)
+			}
+			addSection(fmt.Sprintf("Storage Box %d", i+1), elestrals)
+		}
+	}
+	render()
+
+	recoverWindow.SetContent(container.NewVScroll(content))
+	recoverWindow.Resize(fyne.NewSize(600, 800))
+	recoverWindow.Show()
+}
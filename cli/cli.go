@@ -0,0 +1,236 @@
+// Package cli runs Pandora's Bank commands against a save and bank without
+// opening a window, for scripts, cron jobs and machines without a desktop.
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"pandorasbank/activity"
+	"pandorasbank/backup"
+	"pandorasbank/journal"
+)
+
+// Config is what the app would otherwise read from its settings. --save and
+// --bank override SavePath and BankPath per command.
+type Config struct {
+	SavePath string
+	BankPath string
+	Backups  *backup.Manager
+	// Journal is the transfer journal the app uses, so a transfer either of
+	// them left unfinished is recovered by the other.
+	Journal *journal.Journal
+	// Activity is the log every change is recorded in.
+	Activity *activity.Log
+
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+type command struct {
+	usage       string
+	description string
+	run         func(inv *invocation, args []string) error
+}
+
+// commands is filled in by init to avoid an initialization cycle with help.
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"list":     {"list", "List the Elestrals in the party, storage and bank", runList},
+		"show":     {"show [hash]", "Show the player, or every value of one Elestral", runShow},
+		"export":   {"export <hash> [--to-bank | --to-party]", "Move an Elestral from the party or storage to the bank, or from storage to the party", runExport},
+		"import":   {"import <hash>", "Move an Elestral from the bank to the first free storage slot", runImport},
+		"move":     {"move <hash> <to>", "Move an Elestral anywhere: party[:slot], box:<box>[:slot] or bank[:position]", runMove},
+		"swap":     {"swap <hash> <hash|to>", "Swap two Elestrals, or move one into an empty slot", runSwap},
+		"organise": {"organise [--by key] [--then key] [--empty-at-end] [--preview]", "Sort storage across all boxes by species, element, level, stellar or name", runOrganise},
+		"rename":   {"rename <hash> <name>", "Give an Elestral a new nickname", runRename},
+		"backup":   {"backup [--label text] [--list]", "Snapshot the save, or list the snapshots", runBackup},
+		"restore":  {"restore <backup|latest> [--force]", "Replace the save with a snapshot, backing up the current one first", runRestore},
+		"diff":     {"diff <before> [after]", "Compare two saves; after defaults to the save", runDiff},
+		"validate": {"validate [--fix]", "Check the save and bank for problems, fixing the safe ones with --fix", runValidate},
+		"activity": {"activity [--kind kind] [text]", "Show the log of every change, optionally only one kind or entries mentioning text", runActivity},
+		"repair":   {"repair <file> [--out path]", "Recover what can be read from a damaged save into a new file", runRepair},
+		"help":     {"help", "Show this help", runHelp},
+	}
+}
+
+// IsCommand reports whether arg names a command, so the app only skips its
+// window for arguments meant for it.
+func IsCommand(arg string) bool {
+	switch arg {
+	case "-h", "-help", "--help":
+		return true
+	}
+	_, ok := commands[arg]
+	return ok
+}
+
+// errUsage is returned for bad arguments; the message has already been
+// printed along with the command's usage.
+var errUsage = errors.New("usage")
+
+// Run runs the command in args and returns the process exit code: 0 on
+// success, 1 if the command failed and 2 for bad arguments.
+func Run(args []string, config Config) int {
+	if config.Stdout == nil {
+		config.Stdout = os.Stdout
+	}
+	if config.Stderr == nil {
+		config.Stderr = os.Stderr
+	}
+
+	if len(args) == 0 || !IsCommand(args[0]) {
+		printHelp(config.Stderr)
+		return 2
+	}
+	name := args[0]
+	if name == "-h" || name == "-help" || name == "--help" {
+		name = "help"
+	}
+	cmd := commands[name]
+
+	if config.Journal != nil && name != "help" {
+		tx, outcome, err := config.Journal.Recover()
+		if err != nil {
+			fmt.Fprintf(config.Stderr, "pandorasbank: error recovering an unfinished transfer: %v\n", err)
+			return 1
+		}
+		switch outcome {
+		case journal.Finished:
+			fmt.Fprintf(config.Stderr, "pandorasbank: finished %q, which was interrupted at %s\n", tx.Action, tx.Started.Format("2006-01-02 15:04:05"))
+		case journal.RolledBack:
+			fmt.Fprintf(config.Stderr, "pandorasbank: rolled back %q, which was interrupted at %s and its files changed since\n", tx.Action, tx.Started.Format("2006-01-02 15:04:05"))
+		}
+	}
+
+	inv := &invocation{config: config, name: name, usage: cmd.usage}
+	err := cmd.run(inv, args[1:])
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, errUsage):
+		return 2
+	default:
+		fmt.Fprintf(config.Stderr, "pandorasbank %s: %v\n", name, err)
+		return 1
+	}
+}
+
+func printHelp(w io.Writer) {
+	fmt.Fprintln(w, "Usage: pandorasbank <command> [arguments] [--save path] [--bank path] [--json]")
+	fmt.Fprintln(w, "Run without a command to open the app.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, name := range names {
+		fmt.Fprintf(table, "  %s\t%s\n", commands[name].usage, commands[name].description)
+	}
+	table.Flush()
+}
+
+func runHelp(inv *invocation, args []string) error {
+	printHelp(inv.config.Stdout)
+	return nil
+}
+
+// invocation is one run of a command: its flags and where its output goes.
+type invocation struct {
+	config Config
+	name   string
+	usage  string
+
+	savePath string
+	bankPath string
+	json     bool
+}
+
+// parse parses the command's flags, which may come before, between or after
+// its arguments, and checks the argument count is within min and max (-1 for
+// no limit).
+func (inv *invocation) parse(args []string, min, max int, define func(flags *flag.FlagSet)) ([]string, error) {
+	flags := flag.NewFlagSet(inv.name, flag.ContinueOnError)
+	flags.SetOutput(inv.config.Stderr)
+	flags.StringVar(&inv.savePath, "save", inv.config.SavePath, "save file to use")
+	flags.StringVar(&inv.bankPath, "bank", inv.config.BankPath, "bank file to use")
+	flags.BoolVar(&inv.json, "json", false, "print JSON instead of text")
+	if define != nil {
+		define(flags)
+	}
+	flags.Usage = func() {
+		fmt.Fprintf(inv.config.Stderr, "Usage: pandorasbank %s\n", inv.usage)
+		flags.PrintDefaults()
+	}
+
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, errUsage
+		}
+		args = flags.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+
+	if len(positional) < min || (max >= 0 && len(positional) > max) {
+		flags.Usage()
+		return nil, errUsage
+	}
+	return positional, nil
+}
+
+func (inv *invocation) requireSave() error {
+	if inv.savePath == "" {
+		return errors.New("no save found; pass one with --save")
+	}
+	return nil
+}
+
+// printJSON writes v as indented JSON.
+func (inv *invocation) printJSON(v any) error {
+	data, err := json.MarshalIndent(v, "", "    ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(inv.config.Stdout, string(data))
+	return err
+}
+
+// printTable writes rows under header, lined up in columns.
+func (inv *invocation) printTable(header []string, rows [][]string) error {
+	table := tabwriter.NewWriter(inv.config.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(table, strings.Join(row, "\t"))
+	}
+	return table.Flush()
+}
+
+// printResult reports what a command did, as text or as {"result": ...}.
+func (inv *invocation) printResult(message string) error {
+	if inv.json {
+		return inv.printJSON(struct {
+			Result string `json:"result"`
+		}{message})
+	}
+	_, err := fmt.Fprintln(inv.config.Stdout, message)
+	return err
+}
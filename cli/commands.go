@@ -0,0 +1,768 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"pandorasbank/activity"
+	"pandorasbank/backup"
+	"pandorasbank/gameproc"
+	"pandorasbank/savedata"
+)
+
+type listEntry struct {
+	Location string `json:"location"`
+	Name     string `json:"name"`
+	Species  string `json:"species"`
+	Level    int    `json:"level"`
+	Element  string `json:"element"`
+	Hash     string `json:"hash"`
+}
+
+func runList(inv *invocation, args []string) error {
+	if _, err := inv.parse(args, 0, 0, nil); err != nil {
+		return err
+	}
+	st, err := inv.load()
+	if err != nil {
+		return err
+	}
+
+	entries := []listEntry{}
+	for _, l := range st.elestrals(anywhere) {
+		e := l.elestral
+		entries = append(entries, listEntry{
+			Location: l.String(),
+			Name:     e.Name,
+			Species:  e.Species,
+			Level:    e.CurrentLevel,
+			Element:  savedata.ElementName(e.Element),
+			Hash:     e.ID.Hash,
+		})
+	}
+	if inv.json {
+		return inv.printJSON(entries)
+	}
+
+	var rows [][]string
+	for _, entry := range entries {
+		rows = append(rows, []string{entry.Location, entry.Name, entry.Species, strconv.Itoa(entry.Level), entry.Element, entry.Hash})
+	}
+	return inv.printTable([]string{"LOCATION", "NAME", "SPECIES", "LEVEL", "ELEMENT", "HASH"}, rows)
+}
+
+type playerSummary struct {
+	Name          string   `json:"name"`
+	Gender        string   `json:"gender"`
+	SpiritElement string   `json:"spiritElement"`
+	Money         int      `json:"money"`
+	CasterSP      string   `json:"casterSP"`
+	Party         []string `json:"party"`
+	Storage       int      `json:"storage"`
+	Bank          int      `json:"bank"`
+	SaveVersion   string   `json:"saveVersion"`
+	Scene         string   `json:"scene"`
+	SavedAt       string   `json:"savedAt"`
+}
+
+func runShow(inv *invocation, args []string) error {
+	args, err := inv.parse(args, 0, 1, nil)
+	if err != nil {
+		return err
+	}
+	st, err := inv.load()
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		return showPlayer(inv, st)
+	}
+
+	l, err := st.find(args[0], anywhere)
+	if err != nil {
+		return err
+	}
+	if inv.json {
+		data, err := savedata.Marshal(l.elestral)
+		if err != nil {
+			return err
+		}
+		return inv.printJSON(struct {
+			Location string          `json:"location"`
+			Elestral json.RawMessage `json:"elestral"`
+		}{l.String(), data})
+	}
+
+	e := l.elestral
+	return inv.printTable([]string{"FIELD", "VALUE"}, [][]string{
+		{"Location", l.String()},
+		{"Name", e.Name},
+		{"Species", e.Species},
+		{"Hash", e.ID.Hash},
+		{"Level", strconv.Itoa(e.CurrentLevel)},
+		{"Element", fmt.Sprintf("%s / %s", savedata.ElementName(e.Element), savedata.ElementName(e.SubElement))},
+		{"Health", fmt.Sprintf("%d/%d", e.Health, e.MaxHealth)},
+		{"Physical Attack", strconv.Itoa(e.PhysicalAttack)},
+		{"Special Attack", strconv.Itoa(e.SpecialAttack)},
+		{"Physical Defense", strconv.Itoa(e.PhysicalDefense)},
+		{"Special Defense", strconv.Itoa(e.SpecialDefense)},
+		{"Speed", strconv.Itoa(e.Speed)},
+		{"Abilities", strings.Join([]string{e.Ability0Name, e.Ability1Name, e.Ability2Name, e.Ability3Name}, ", ")},
+		{"Empowered Ability", e.EmpoweredAbilityName},
+		{"Stellar", strconv.FormatBool(e.IsStellar)},
+	})
+}
+
+func showPlayer(inv *invocation, st *state) error {
+	player := st.gameSave.ActivePlayerData
+	gender := "Female"
+	if player.IsMaleCharacter {
+		gender = "Male"
+	}
+	storage := len(st.elestrals(inStorage))
+
+	summary := playerSummary{
+		Name:          player.Name,
+		Gender:        gender,
+		SpiritElement: savedata.ElementName(player.SpiritElement),
+		Money:         player.Money,
+		CasterSP:      fmt.Sprintf("%d/%d", player.CurrentSp, player.MaxSp),
+		Party:         st.gameSave.PartySpecies(),
+		Storage:       storage,
+		Bank:          len(st.bank.Elestrals),
+		SaveVersion:   savedata.DisplayVersion(st.gameSave.SaveVersion),
+		Scene:         st.gameSave.CurrentSceneName,
+		SavedAt:       st.gameSave.SaveTimestamp,
+	}
+	if inv.json {
+		return inv.printJSON(summary)
+	}
+
+	return inv.printTable([]string{"FIELD", "VALUE"}, [][]string{
+		{"Name", summary.Name},
+		{"Gender", summary.Gender},
+		{"Spirit Element", summary.SpiritElement},
+		{"Money", strconv.Itoa(summary.Money)},
+		{"Caster SP", summary.CasterSP},
+		{"Party", strings.Join(summary.Party, ", ")},
+		{"Storage", fmt.Sprintf("%d Elestrals", summary.Storage)},
+		{"Bank", fmt.Sprintf("%d Elestrals", summary.Bank)},
+		{"Save Version", summary.SaveVersion},
+		{"Scene", summary.Scene},
+		{"Saved At", summary.SavedAt},
+	})
+}
+
+func runExport(inv *invocation, args []string) error {
+	var toBank, toParty bool
+	args, err := inv.parse(args, 1, 1, func(flags *flag.FlagSet) {
+		flags.BoolVar(&toBank, "to-bank", false, "move the Elestral to the bank (the default)")
+		flags.BoolVar(&toParty, "to-party", false, "move the Elestral from storage to the first free party slot")
+	})
+	if err != nil {
+		return err
+	}
+	if toBank && toParty {
+		return errors.New("--to-bank and --to-party can't be used together")
+	}
+
+	return inv.edit(func(st *state) (string, error) {
+		if toParty {
+			l, err := st.find(args[0], inStorage)
+			if err != nil {
+				return "", err
+			}
+			elestralName := l.elestral.Name
+			partySlot, err := savedata.MoveToParty(st.gameSave, st.bank, l.at)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%s has been moved to party Slot %d!", elestralName, partySlot+1), nil
+		}
+
+		l, err := st.find(args[0], inParty|inStorage)
+		if err != nil {
+			return "", err
+		}
+		elestralName := l.elestral.Name
+		if err := savedata.ExportToBank(st.gameSave, st.bank, l.at); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s has been exported to the bank!", elestralName), nil
+	})
+}
+
+func runImport(inv *invocation, args []string) error {
+	args, err := inv.parse(args, 1, 1, nil)
+	if err != nil {
+		return err
+	}
+
+	return inv.edit(func(st *state) (string, error) {
+		l, err := st.find(args[0], inBank)
+		if err != nil {
+			return "", err
+		}
+		slot, err := savedata.ImportFromBank(st.gameSave, st.bank, l.at.Index)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s has been imported to Storage Box %d!", l.elestral.Name, slot.Box+1), nil
+	})
+}
+
+func runRename(inv *invocation, args []string) error {
+	args, err := inv.parse(args, 2, 2, nil)
+	if err != nil {
+		return err
+	}
+
+	return inv.edit(func(st *state) (string, error) {
+		l, err := st.find(args[0], anywhere)
+		if err != nil {
+			return "", err
+		}
+		oldName := l.elestral.Name
+		if err := savedata.Rename(l.elestral, args[1]); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Renamed %s to %s.", oldName, l.elestral.Name), nil
+	})
+}
+
+type backupEntry struct {
+	Time   time.Time     `json:"time"`
+	Reason backup.Reason `json:"reason"`
+	Label  string        `json:"label,omitempty"`
+	Path   string        `json:"path"`
+}
+
+func runBackup(inv *invocation, args []string) error {
+	var label string
+	var list bool
+	if _, err := inv.parse(args, 0, 0, func(flags *flag.FlagSet) {
+		flags.StringVar(&label, "label", "", "name the snapshot so it is never pruned")
+		flags.BoolVar(&list, "list", false, "list the snapshots instead of taking one")
+	}); err != nil {
+		return err
+	}
+	manager := inv.config.Backups
+	if manager == nil {
+		return errors.New("no backup directory found")
+	}
+
+	if list {
+		snapshots, err := manager.List()
+		if err != nil {
+			return err
+		}
+		entries := []backupEntry{}
+		for _, snapshot := range snapshots {
+			entries = append(entries, backupEntry{snapshot.Time, snapshot.Reason, snapshot.Label, snapshot.Path})
+		}
+		if inv.json {
+			return inv.printJSON(entries)
+		}
+
+		var rows [][]string
+		for _, entry := range entries {
+			rows = append(rows, []string{entry.Time.Format("2006-01-02 15:04:05"), string(entry.Reason), entry.Label, filepath.Base(entry.Path)})
+		}
+		return inv.printTable([]string{"TIME", "REASON", "LABEL", "FILE"}, rows)
+	}
+
+	if err := inv.requireSave(); err != nil {
+		return err
+	}
+	snapshot, err := manager.Snapshot(inv.savePath, backup.ReasonManual)
+	if err != nil {
+		return err
+	}
+	if label != "" {
+		if err := manager.SetLabel(snapshot, label); err != nil {
+			return fmt.Errorf("error labelling backup: %w", err)
+		}
+	}
+	return inv.printResult(snapshot.Path)
+}
+
+// findBackup resolves a backup given as a path, a file name in the backup
+// directory or "latest".
+func findBackup(manager *backup.Manager, name string) (string, error) {
+	if name == "latest" {
+		snapshots, err := manager.List()
+		if err != nil {
+			return "", err
+		}
+		if len(snapshots) == 0 {
+			return "", errors.New("there are no backups yet")
+		}
+		return snapshots[0].Path, nil
+	}
+
+	if _, err := os.Stat(name); err == nil {
+		return name, nil
+	}
+	path := filepath.Join(manager.Dir, name)
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("no backup %s", name)
+	}
+	return path, nil
+}
+
+func runRestore(inv *invocation, args []string) error {
+	var force bool
+	args, err := inv.parse(args, 1, 1, func(flags *flag.FlagSet) {
+		flags.BoolVar(&force, "force", false, "restore even if the backup is from a different save version")
+	})
+	if err != nil {
+		return err
+	}
+	if err := inv.requireSave(); err != nil {
+		return err
+	}
+	manager := inv.config.Backups
+	if manager == nil {
+		return errors.New("no backup directory found")
+	}
+	if err := gameproc.CheckClosed(); err != nil {
+		return err
+	}
+
+	backupPath, err := findBackup(manager, args[0])
+	if err != nil {
+		return err
+	}
+	backupSave, err := savedata.LoadSave(backupPath)
+	if err != nil {
+		return fmt.Errorf("%s can't be restored because it is not a valid save: %w", backupPath, err)
+	}
+	if liveSave, err := savedata.LoadSave(inv.savePath); err == nil && liveSave.SaveVersion != backupSave.SaveVersion && !force {
+		return fmt.Errorf("the backup is from save version %s but the save is version %s and the game may not load it correctly; pass --force to restore anyway",
+			savedata.DisplayVersion(backupSave.SaveVersion), savedata.DisplayVersion(liveSave.SaveVersion))
+	}
+
+	liveSave, _ := savedata.LoadSave(inv.savePath)
+	if err := manager.Restore(backupPath, inv.savePath, savedata.VerifySave); err != nil {
+		return err
+	}
+	inv.logActivity(activity.Restored(inv.savePath, backupPath, liveSave, backupSave, nil, nil)...)
+	return inv.printResult(fmt.Sprintf("Restored %s from %s.", inv.savePath, backupPath))
+}
+
+func runDiff(inv *invocation, args []string) error {
+	args, err := inv.parse(args, 1, 2, nil)
+	if err != nil {
+		return err
+	}
+
+	afterPath := inv.savePath
+	if len(args) == 2 {
+		afterPath = args[1]
+	} else if err := inv.requireSave(); err != nil {
+		return err
+	}
+
+	before, err := savedata.LoadSave(args[0])
+	if err != nil {
+		return fmt.Errorf("error reading %s: %w", args[0], err)
+	}
+	after, err := savedata.LoadSave(afterPath)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %w", afterPath, err)
+	}
+
+	diff := savedata.Diff(before, after)
+	if inv.json {
+		data, err := diff.JSON()
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(inv.config.Stdout, string(data))
+		return err
+	}
+	_, err = fmt.Fprintln(inv.config.Stdout, diff.Text())
+	return err
+}
+
+type fileCheck struct {
+	Path     string   `json:"path"`
+	Version  string   `json:"version"`
+	Problems []string `json:"problems"`
+	Notes    []string `json:"notes"`
+}
+
+// checkVersion notes the updates the app would make, or a problem if the file
+// is of a version that won't be written.
+func (check *fileCheck) checkVersion(version string, migrate func() (savedata.MigrationReport, error)) {
+	check.Version = savedata.DisplayVersion(version)
+	report, err := migrate()
+	if err != nil {
+		check.Problems = append(check.Problems, err.Error())
+	} else if report.Migrated() {
+		check.Notes = append(check.Notes, fmt.Sprintf("will be updated when next written: %s", strings.Join(report.Changes, "; ")))
+	}
+}
+
+func runValidate(inv *invocation, args []string) error {
+	var fix bool
+	if _, err := inv.parse(args, 0, 0, func(flags *flag.FlagSet) {
+		flags.BoolVar(&fix, "fix", false, "apply every safe fix first")
+	}); err != nil {
+		return err
+	}
+	if err := inv.requireSave(); err != nil {
+		return err
+	}
+
+	fixed := []savedata.Finding{}
+	if fix {
+		err := inv.edit(func(st *state) (string, error) {
+			fixed = append(fixed, savedata.FixAll(st.gameSave, st.bank)...)
+			// Reported along with what's left below.
+			return "", nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	saveCheck := fileCheck{Path: inv.savePath, Problems: []string{}, Notes: []string{}}
+	gameSave, err := savedata.LoadSave(inv.savePath)
+	if err != nil {
+		saveCheck.Problems = append(saveCheck.Problems, err.Error())
+	} else {
+		saveCheck.checkVersion(gameSave.SaveVersion, func() (savedata.MigrationReport, error) {
+			return savedata.MigrateSave(gameSave)
+		})
+	}
+
+	checks := []fileCheck{saveCheck}
+	var bank *savedata.Bank
+	if inv.bankPath != "" {
+		bankCheck := fileCheck{Path: inv.bankPath, Problems: []string{}, Notes: []string{}}
+		if bank, err = savedata.LoadBank(inv.bankPath); err != nil {
+			bankCheck.Problems = append(bankCheck.Problems, err.Error())
+		} else {
+			bankCheck.checkVersion(bank.SaveVersion, func() (savedata.MigrationReport, error) {
+				return savedata.MigrateBank(bank)
+			})
+		}
+		checks = append(checks, bankCheck)
+	}
+
+	findings := []savedata.Finding{}
+	if gameSave != nil {
+		findings = append(findings, savedata.Validate(gameSave, bank)...)
+	}
+
+	problems := 0
+	for _, check := range checks {
+		problems += len(check.Problems)
+	}
+	for _, finding := range findings {
+		if finding.Severity == savedata.SeverityError {
+			problems++
+		}
+	}
+
+	if inv.json {
+		if err := inv.printJSON(struct {
+			Files    []fileCheck        `json:"files"`
+			Fixed    []savedata.Finding `json:"fixed,omitempty"`
+			Findings []savedata.Finding `json:"findings"`
+		}{checks, fixed, findings}); err != nil {
+			return err
+		}
+	} else {
+		for _, check := range checks {
+			status := "ok"
+			if len(check.Problems) > 0 {
+				status = fmt.Sprintf("%d problems", len(check.Problems))
+			}
+			fmt.Fprintf(inv.config.Stdout, "%s: %s (version %s)\n", check.Path, status, check.Version)
+			for _, problem := range check.Problems {
+				fmt.Fprintf(inv.config.Stdout, "  error: %s\n", problem)
+			}
+			for _, note := range check.Notes {
+				fmt.Fprintf(inv.config.Stdout, "  note: %s\n", note)
+			}
+		}
+		for _, finding := range fixed {
+			fmt.Fprintf(inv.config.Stdout, "fixed: %s: %s\n", finding.Location, finding.FixDescription)
+		}
+		for _, finding := range findings {
+			line := finding.String()
+			if finding.CanFix() {
+				line += fmt.Sprintf(" (fix: %s)", finding.FixDescription)
+			}
+			fmt.Fprintln(inv.config.Stdout, line)
+		}
+	}
+
+	if problems > 0 {
+		return fmt.Errorf("found %d problems", problems)
+	}
+	return nil
+}
+
+func runRepair(inv *invocation, args []string) error {
+	var outPath string
+	args, err := inv.parse(args, 1, 1, func(flags *flag.FlagSet) {
+		flags.StringVar(&outPath, "out", "", "where to write the repaired save (default: next to it, with _repaired in the name)")
+	})
+	if err != nil {
+		return err
+	}
+
+	inPath := args[0]
+	data, err := os.ReadFile(inPath)
+	if err != nil {
+		return fmt.Errorf("error reading save: %w", err)
+	}
+	gameSave, report, err := savedata.RepairSave(data)
+	if err != nil {
+		return fmt.Errorf("the save could not be repaired: %w", err)
+	}
+
+	// Repair never touches the original or anything else already there.
+	if outPath == "" {
+		outPath = savedata.RepairedPath(inPath)
+	} else {
+		inAbs, _ := filepath.Abs(inPath)
+		outAbs, _ := filepath.Abs(outPath)
+		if inAbs == outAbs {
+			return errors.New("the repaired save can't replace the original; choose another --out path")
+		}
+		if _, err := os.Stat(outPath); err == nil {
+			return fmt.Errorf("%s already exists", outPath)
+		}
+	}
+	if err := savedata.WriteSave(outPath, gameSave, savedata.WriteOptions{}); err != nil {
+		return fmt.Errorf("error writing repaired save: %w", err)
+	}
+
+	if inv.json {
+		return inv.printJSON(struct {
+			Path   string                `json:"path"`
+			Report savedata.RepairReport `json:"report"`
+		}{outPath, report})
+	}
+	fmt.Fprintln(inv.config.Stdout, report.String())
+	fmt.Fprintf(inv.config.Stdout, "Wrote the repaired save to %s.\n", outPath)
+	return nil
+}
+
+func runActivity(inv *invocation, args []string) error {
+	var kind string
+	args, err := inv.parse(args, 0, 1, func(flags *flag.FlagSet) {
+		flags.StringVar(&kind, "kind", "", "only show entries of this kind (rename, export, import, move, add, release, remove, gender or restore)")
+	})
+	if err != nil {
+		return err
+	}
+	var query string
+	if len(args) == 1 {
+		query = args[0]
+	}
+
+	entries, skipped, err := inv.activity().Read()
+	if err != nil {
+		return err
+	}
+	if skipped > 0 {
+		fmt.Fprintf(inv.config.Stderr, "pandorasbank %s: skipped %d unreadable lines\n", inv.name, skipped)
+	}
+	entries = activity.Filter(entries, activity.Kind(kind), query)
+
+	if inv.json {
+		if entries == nil {
+			entries = []activity.Entry{}
+		}
+		return inv.printJSON(entries)
+	}
+
+	var rows [][]string
+	for _, entry := range entries {
+		var name, hash string
+		if entry.Elestral != nil {
+			name, hash = entry.Elestral.Name, entry.Elestral.Hash
+		}
+		rows = append(rows, []string{entry.Time.Local().Format("2006-01-02 15:04:05"), entry.User, string(entry.Kind), name, hash, entry.From, entry.To, entry.Action})
+	}
+	return inv.printTable([]string{"TIME", "USER", "KIND", "NAME", "HASH", "FROM", "TO", "ACTION"}, rows)
+}
+
+func runMove(inv *invocation, args []string) error {
+	args, err := inv.parse(args, 2, 2, nil)
+	if err != nil {
+		return err
+	}
+
+	return inv.edit(func(st *state) (string, error) {
+		l, err := st.find(args[0], anywhere)
+		if err != nil {
+			return "", err
+		}
+		to, err := st.parseLocation(args[1])
+		if err != nil {
+			return "", err
+		}
+		elestralName := l.elestral.Name
+		if to == l.at {
+			return fmt.Sprintf("%s is already in %s.", elestralName, to), nil
+		}
+		hash := l.elestral.ID.Hash
+		if err := savedata.Move(st.gameSave, st.bank, l.at, to); err != nil {
+			return "", err
+		}
+		// The party closes up behind a move, so look again for where it went.
+		if moved, err := st.find(hash, anywhere); err == nil {
+			to = moved.at
+		}
+		return fmt.Sprintf("Moved %s from %s to %s.", elestralName, l.at, to), nil
+	})
+}
+
+func runSwap(inv *invocation, args []string) error {
+	args, err := inv.parse(args, 2, 2, nil)
+	if err != nil {
+		return err
+	}
+
+	return inv.edit(func(st *state) (string, error) {
+		a, err := st.find(args[0], anywhere)
+		if err != nil {
+			return "", err
+		}
+		b, err := st.find(args[1], anywhere)
+		if err != nil {
+			// Not a hash, so a location, which may be empty.
+			at, parseErr := st.parseLocation(args[1])
+			if parseErr != nil {
+				return "", err
+			}
+			b = located{at: at}
+			b.elestral, _ = savedata.Get(st.gameSave, st.bank, at)
+		}
+
+		// Swapping changes what the pointers hold, so take the names first.
+		aName, aHash := a.elestral.Name, a.elestral.ID.Hash
+		if b.elestral == nil {
+			if err := savedata.Move(st.gameSave, st.bank, a.at, b.at); err != nil {
+				return "", err
+			}
+			if moved, err := st.find(aHash, anywhere); err == nil {
+				b.at = moved.at
+			}
+			return fmt.Sprintf("Moved %s from %s to %s.", aName, a.at, b.at), nil
+		}
+		bName := b.elestral.Name
+		if err := savedata.Swap(st.gameSave, st.bank, a.at, b.at); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Swapped %s (%s) and %s (%s).", aName, a.at, bName, b.at), nil
+	})
+}
+
+type organiseEntry struct {
+	Location string `json:"location"`
+	Name     string `json:"name"`
+	Species  string `json:"species"`
+	Level    int    `json:"level"`
+	Hash     string `json:"hash"`
+	// From is where the Elestral is now, if it moves.
+	From string `json:"from,omitempty"`
+}
+
+// organiseEntries lists the Elestrals of layout in the order they'd be in.
+func organiseEntries(gameSave *savedata.GameSave, layout savedata.Layout) []organiseEntry {
+	now := map[*savedata.Elestral]savedata.Location{}
+	savedata.Each(gameSave, nil, func(l savedata.Location, e *savedata.Elestral) {
+		now[e] = l
+	}, savedata.InStorage)
+
+	entries := []organiseEntry{}
+	for boxIdx, box := range layout {
+		for entryIdx, e := range box {
+			if e == nil {
+				continue
+			}
+			at := savedata.AtStorage(savedata.StorageSlot{Box: boxIdx, Entry: entryIdx})
+			entry := organiseEntry{Location: at.String(), Name: e.Name, Species: e.Species, Level: e.CurrentLevel, Hash: e.ID.Hash}
+			if from := now[e]; from != at {
+				entry.From = from.String()
+			}
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func runOrganise(inv *invocation, args []string) error {
+	var by, thenBy string
+	var emptyAtEnd, preview bool
+	if _, err := inv.parse(args, 0, 0, func(flags *flag.FlagSet) {
+		flags.StringVar(&by, "by", string(savedata.BySpecies), "what to sort by: species, element, level, stellar or name")
+		flags.StringVar(&thenBy, "then", "", "what to sort Elestrals that tie by")
+		flags.BoolVar(&emptyAtEnd, "empty-at-end", false, "pack the Elestrals together so the empty entries come last")
+		flags.BoolVar(&preview, "preview", false, "show the new layout without writing it")
+	}); err != nil {
+		return err
+	}
+
+	options := savedata.OrganiseOptions{EmptyAtEnd: emptyAtEnd}
+	var err error
+	if options.By, err = savedata.ParseSortKey(by); err != nil {
+		return err
+	}
+	if thenBy != "" {
+		if options.ThenBy, err = savedata.ParseSortKey(thenBy); err != nil {
+			return err
+		}
+	}
+
+	if preview {
+		st, err := inv.load()
+		if err != nil {
+			return err
+		}
+		layout, err := savedata.PlanOrganise(st.gameSave, options)
+		if err != nil {
+			return err
+		}
+		entries := organiseEntries(st.gameSave, layout)
+		if inv.json {
+			return inv.printJSON(entries)
+		}
+		var rows [][]string
+		for _, entry := range entries {
+			rows = append(rows, []string{entry.Location, entry.Name, entry.Species, strconv.Itoa(entry.Level), entry.From})
+		}
+		return inv.printTable([]string{"LOCATION", "NAME", "SPECIES", "LEVEL", "FROM"}, rows)
+	}
+
+	return inv.edit(func(st *state) (string, error) {
+		moved, err := savedata.Organise(st.gameSave, options)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Organised storage by %s; %d Elestrals moved.", organiseDescription(options), moved), nil
+	})
+}
+
+func organiseDescription(options savedata.OrganiseOptions) string {
+	description := string(options.By)
+	if options.ThenBy != "" {
+		description += " then " + string(options.ThenBy)
+	}
+	return description
+}
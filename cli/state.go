@@ -0,0 +1,314 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"pandorasbank/activity"
+	"pandorasbank/backup"
+	"pandorasbank/gameproc"
+	"pandorasbank/journal"
+	"pandorasbank/savedata"
+)
+
+// state is the save and bank a command works on, upgraded the same way the
+// app upgrades them when a save is opened.
+type state struct {
+	gameSave *savedata.GameSave
+	bank     *savedata.Bank
+	// unwritable is why the save or bank can't be written, if either is of a
+	// version that is refused. Commands that only read can still use them.
+	unwritable error
+}
+
+func (inv *invocation) load() (*state, error) {
+	if err := inv.requireSave(); err != nil {
+		return nil, err
+	}
+
+	saveData, err := os.ReadFile(inv.savePath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading save: %w", err)
+	}
+	gameSave, err := savedata.ParseSave(saveData)
+	if err != nil {
+		return nil, fmt.Errorf("error reading save: %w", err)
+	}
+	// A save that can't be upgraded can still be looked at; edit refuses to
+	// change it.
+	_, saveErr := savedata.MigrateSave(gameSave)
+
+	bank := savedata.NewBank()
+	var bankErr error
+	if inv.bankPath != "" {
+		if bank, err = savedata.LoadBank(inv.bankPath); err != nil {
+			return nil, fmt.Errorf("error loading bank: %w", err)
+		}
+		if _, err := savedata.MigrateBank(bank); err != nil {
+			bankErr = fmt.Errorf("bank: %w", err)
+		}
+	}
+
+	return &state{gameSave: gameSave, bank: bank, unwritable: errors.Join(saveErr, bankErr)}, nil
+}
+
+// besideBank is where a file the app keeps next to the bank goes when the
+// config doesn't say: next to the bank, or the save without a bank.
+func (inv *invocation) besideBank(name string) string {
+	dir := filepath.Dir(inv.bankPath)
+	if inv.bankPath == "" {
+		dir = filepath.Dir(inv.savePath)
+	}
+	return filepath.Join(dir, name)
+}
+
+// journal is the configured transfer journal, or one next to the bank when
+// there is none, so edits are always journaled.
+func (inv *invocation) journal() *journal.Journal {
+	if inv.config.Journal != nil {
+		return inv.config.Journal
+	}
+	return journal.New(inv.besideBank("pbank_journal.json"))
+}
+
+// activity is the configured activity log, or one next to the bank.
+func (inv *invocation) activity() *activity.Log {
+	if inv.config.Activity != nil {
+		return inv.config.Activity
+	}
+	return activity.New(inv.besideBank("pbank_activity.jsonl"), "command line")
+}
+
+// logActivity records entries for a change that has already been made, so
+// failing to only warns.
+func (inv *invocation) logActivity(entries ...activity.Entry) {
+	if err := inv.activity().Append(entries...); err != nil {
+		fmt.Fprintf(inv.config.Stderr, "pandorasbank %s: warning: error writing activity log: %v\n", inv.name, err)
+	}
+}
+
+// edit loads the save and bank, lets change modify them and writes back
+// whichever of the two it changed, the same way the app does: the save is
+// backed up first, and both are written as one journaled transaction so an
+// Elestral can't end up in both or in neither. change returns the message to
+// print, if any.
+func (inv *invocation) edit(change func(st *state) (string, error)) error {
+	if err := gameproc.CheckClosed(); err != nil {
+		return err
+	}
+	st, err := inv.load()
+	if err != nil {
+		return err
+	}
+	if st.unwritable != nil {
+		return fmt.Errorf("refusing to change the files: %w", st.unwritable)
+	}
+
+	saveBefore, err := savedata.Marshal(st.gameSave)
+	if err != nil {
+		return err
+	}
+	bankBefore, err := json.Marshal(st.bank)
+	if err != nil {
+		return err
+	}
+	// Kept as they were to log what the change did.
+	var original state
+	if original.gameSave, err = savedata.ParseSave(saveBefore); err != nil {
+		return err
+	}
+	original.bank = &savedata.Bank{}
+	if err := json.Unmarshal(bankBefore, original.bank); err != nil {
+		return err
+	}
+
+	message, err := change(st)
+	if err != nil {
+		return err
+	}
+
+	saveAfter, err := savedata.Marshal(st.gameSave)
+	if err != nil {
+		return err
+	}
+	bankAfter, err := json.Marshal(st.bank)
+	if err != nil {
+		return err
+	}
+	saveChanged := !bytes.Equal(saveBefore, saveAfter)
+	bankChanged := !bytes.Equal(bankBefore, bankAfter)
+	if bankChanged && inv.bankPath == "" {
+		return errors.New("no bank file found; pass one with --bank")
+	}
+
+	var writes []journal.Write
+	if saveChanged {
+		data, err := savedata.EncodeSave(st.gameSave)
+		if err != nil {
+			return err
+		}
+		if inv.config.Backups != nil {
+			if _, err := os.Stat(inv.savePath); err == nil {
+				if _, err := inv.config.Backups.Snapshot(inv.savePath, backup.ReasonEdit); err != nil {
+					return fmt.Errorf("error backing up save: %w", err)
+				}
+			}
+		}
+		writes = append(writes, journal.Write{Path: inv.savePath, Data: data, Verify: savedata.VerifySave})
+	}
+	if bankChanged {
+		data, err := savedata.EncodeBank(st.bank)
+		if err != nil {
+			return fmt.Errorf("error saving bank: %w", err)
+		}
+		writes = append(writes, journal.Write{Path: inv.bankPath, Data: data, Verify: savedata.VerifyBank})
+	}
+	if len(writes) > 0 {
+		action := "pandorasbank " + inv.name
+		if err := inv.journal().Commit(action, writes); err != nil {
+			return err
+		}
+		inv.logActivity(activity.Changes(action, inv.savePath, original.gameSave, st.gameSave, original.bank, st.bank)...)
+	}
+
+	if message == "" {
+		return nil
+	}
+	return inv.printResult(message)
+}
+
+type place int
+
+const (
+	inParty place = 1 << iota
+	inStorage
+	inBank
+
+	anywhere = inParty | inStorage | inBank
+)
+
+// located is an Elestral and where it is.
+type located struct {
+	elestral *savedata.Elestral
+	at       savedata.Location
+}
+
+func (l located) String() string {
+	return l.at.String()
+}
+
+// areas are the savedata areas that make up places.
+func (places place) areas() []savedata.Area {
+	var areas []savedata.Area
+	for _, pair := range []struct {
+		place place
+		area  savedata.Area
+	}{{inParty, savedata.InParty}, {inStorage, savedata.InStorage}, {inBank, savedata.InBank}} {
+		if places&pair.place != 0 {
+			areas = append(areas, pair.area)
+		}
+	}
+	return areas
+}
+
+// elestrals lists the Elestrals in places, skipping empty slots.
+func (st *state) elestrals(places place) []located {
+	var found []located
+	savedata.Each(st.gameSave, st.bank, func(at savedata.Location, e *savedata.Elestral) {
+		found = append(found, located{elestral: e, at: at})
+	}, places.areas()...)
+	return found
+}
+
+// find looks up the one Elestral in places whose hash is, or starts with,
+// hash.
+func (st *state) find(hash string, places place) (located, error) {
+	var exact, prefixed []located
+	for _, l := range st.elestrals(places) {
+		switch {
+		case l.elestral.ID.Hash == hash:
+			exact = append(exact, l)
+		case hash != "" && strings.HasPrefix(l.elestral.ID.Hash, hash):
+			prefixed = append(prefixed, l)
+		}
+	}
+
+	matches := exact
+	if len(matches) == 0 {
+		matches = prefixed
+	}
+	switch len(matches) {
+	case 0:
+		return located{}, fmt.Errorf("no Elestral with hash %q in the %s", hash, placeNames(places))
+	case 1:
+		return matches[0], nil
+	}
+
+	var where []string
+	for _, l := range matches {
+		where = append(where, fmt.Sprintf("%s (%s)", l, l.elestral.Name))
+	}
+	return located{}, fmt.Errorf("hash %q matches %d Elestrals: %s", hash, len(matches), strings.Join(where, ", "))
+}
+
+func placeNames(places place) string {
+	var names []string
+	if places&inParty != 0 {
+		names = append(names, "party")
+	}
+	if places&inStorage != 0 {
+		names = append(names, "storage")
+	}
+	if places&inBank != 0 {
+		names = append(names, "bank")
+	}
+	if len(names) == 1 {
+		return names[0]
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " or " + names[len(names)-1]
+}
+
+// parseLocation reads a location given as party[:slot], box:<box>[:slot] or
+// bank[:position], one based. Leaving out the slot picks the first free one
+// (the end of the bank), for a destination.
+func (st *state) parseLocation(text string) (savedata.Location, error) {
+	parts := strings.Split(strings.ToLower(text), ":")
+	numbers := make([]int, len(parts)-1)
+	for i, part := range parts[1:] {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 1 {
+			return savedata.Location{}, fmt.Errorf("%q in %q is not a number from 1 up", part, text)
+		}
+		numbers[i] = n - 1
+	}
+
+	switch {
+	case parts[0] == "party" && len(numbers) == 0:
+		slot, found := savedata.FreePartySlot(st.gameSave)
+		if !found {
+			return savedata.Location{}, savedata.ErrPartyFull
+		}
+		return savedata.AtParty(slot), nil
+	case parts[0] == "party" && len(numbers) == 1:
+		return savedata.AtParty(numbers[0]), nil
+	case parts[0] == "box" && len(numbers) == 1:
+		slot, found := savedata.FreeSlotInBox(st.gameSave, numbers[0])
+		if !found {
+			return savedata.Location{}, fmt.Errorf("Storage Box %d has no free slot", numbers[0]+1)
+		}
+		return savedata.AtStorage(slot), nil
+	case parts[0] == "box" && len(numbers) == 2:
+		return savedata.AtStorage(savedata.StorageSlot{Box: numbers[0], Entry: numbers[1]}), nil
+	case parts[0] == "bank" && len(numbers) == 0:
+		return savedata.BankEnd(st.bank), nil
+	case parts[0] == "bank" && len(numbers) == 1:
+		return savedata.AtBank(numbers[0]), nil
+	}
+	return savedata.Location{}, fmt.Errorf("%q is not a location; use party[:slot], box:<box>[:slot] or bank[:position]", text)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"pandorasbank/savedata"
+)
+
+// showSaveDiff opens a window with diff as text and lets it be exported.
+func showSaveDiff(title string, diff savedata.SaveDiff) {
+	diffWindow := fyne.CurrentApp().NewWindow(title)
+
+	text := widget.NewLabel(diff.Text())
+	text.TextStyle = fyne.TextStyle{Monospace: true}
+
+	exportAs := func(fileName string, render func() ([]byte, error)) {
+		saveDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, diffWindow)
+				return
+			}
+			if writer == nil {
+				return
+			}
+			defer writer.Close()
+
+			data, err := render()
+			if err != nil {
+				dialog.ShowError(err, diffWindow)
+				return
+			}
+			if _, err := writer.Write(data); err != nil {
+				dialog.ShowError(fmt.Errorf("error writing diff: %w", err), diffWindow)
+			}
+		}, diffWindow)
+		saveDialog.SetFileName(fileName)
+		saveDialog.Show()
+	}
+
+	exportTextButton := widget.NewButton("Export Text...", func() {
+		exportAs("save_diff.txt", func() ([]byte, error) {
+			return []byte(diff.Text() + "\n"), nil
+		})
+	})
+	exportJSONButton := widget.NewButton("Export JSON...", func() {
+		exportAs("save_diff.json", diff.JSON)
+	})
+
+	summary := widget.NewLabel(fmt.Sprintf("%d changes", len(diff.Changes)))
+	toolbar := container.NewHBox(summary, exportTextButton, exportJSONButton)
+
+	diffWindow.SetContent(container.NewBorder(toolbar, nil, nil, nil, container.NewScroll(text)))
+	diffWindow.Resize(fyne.NewSize(700, 600))
+	diffWindow.Show()
+}
+
+// compareSaveFiles shows what changed going from the save at beforePath to
+// the one at afterPath.
+func compareSaveFiles(beforePath, afterPath string, myWindow fyne.Window) {
+	before, err := savedata.LoadSave(beforePath)
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("error reading %s: %w", filepath.Base(beforePath), err), myWindow)
+		return
+	}
+	after, err := savedata.LoadSave(afterPath)
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("error reading %s: %w", filepath.Base(afterPath), err), myWindow)
+		return
+	}
+
+	showSaveDiff(fmt.Sprintf("%s -> %s", filepath.Base(beforePath), filepath.Base(afterPath)), savedata.Diff(before, after))
+}
+
+func pickSaveFile(myWindow fyne.Window, onPicked func(path string)) {
+	dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, myWindow)
+			return
+		}
+		if reader == nil {
+			return
+		}
+		defer reader.Close()
+
+		onPicked(reader.URI().Path())
+	}, myWindow)
+}
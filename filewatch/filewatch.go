@@ -0,0 +1,140 @@
+package filewatch
+
+import (
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Watcher calls onChange once things settle after any of a set of files is
+// written, created, removed or renamed over. It watches the files' folders
+// rather than the files themselves, so files replaced by a rename (the way
+// atomicfile and most games write) keep being watched.
+type Watcher struct {
+	watcher  *fsnotify.Watcher
+	delay    time.Duration
+	onChange func()
+	onError  func(error)
+
+	mu    sync.Mutex
+	files map[string]bool
+	dirs  map[string]bool
+	timer *time.Timer
+}
+
+// New starts a Watcher with nothing to watch. onChange and onError are called
+// from a goroutine. delay is how long the files have to stay quiet before
+// onChange runs, so a burst of writes is reported once.
+func New(delay time.Duration, onChange func(), onError func(error)) (*Watcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Watcher{
+		watcher:  watcher,
+		delay:    delay,
+		onChange: onChange,
+		onError:  onError,
+		files:    map[string]bool{},
+		dirs:     map[string]bool{},
+	}
+	go w.run()
+	return w, nil
+}
+
+// Set replaces the watched files with paths. Empty paths are skipped.
+func (w *Watcher) Set(paths ...string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	files := map[string]bool{}
+	dirs := map[string]bool{}
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		path = filepath.Clean(path)
+		files[path] = true
+		dirs[filepath.Dir(path)] = true
+	}
+
+	for dir := range w.dirs {
+		if !dirs[dir] {
+			w.watcher.Remove(dir)
+		}
+	}
+	var firstErr error
+	for dir := range dirs {
+		if w.dirs[dir] {
+			continue
+		}
+		if err := w.watcher.Add(dir); err != nil {
+			delete(dirs, dir)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	w.files = files
+	w.dirs = dirs
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	return firstErr
+}
+
+func (w *Watcher) Close() error {
+	w.mu.Lock()
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	w.mu.Unlock()
+	return w.watcher.Close()
+}
+
+func (w *Watcher) run() {
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Has(fsnotify.Chmod) && !event.Has(fsnotify.Write|fsnotify.Create|fsnotify.Remove|fsnotify.Rename) {
+				continue
+			}
+			w.changed(filepath.Clean(event.Name))
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			if w.onError != nil {
+				w.onError(err)
+			}
+		}
+	}
+}
+
+func (w *Watcher) changed(path string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.files[path] {
+		return
+	}
+	if w.timer != nil {
+		w.timer.Reset(w.delay)
+		return
+	}
+	w.timer = time.AfterFunc(w.delay, func() {
+		w.mu.Lock()
+		w.timer = nil
+		w.mu.Unlock()
+		w.onChange()
+	})
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+)
+
+// gamePollInterval is how often the process list is checked for a game that
+// wasn't started from the Start Game button.
+const gamePollInterval = 3 * time.Second
+
+func (bankWindow *BankWindow) gameRunning() bool {
+	return bankWindow.gameLaunched || bankWindow.gameProcessRunning
+}
+
+// updateEditLock makes the open save read-only while the game is running and
+// shows a banner saying why. Once the game exits the save is checked for
+// anything the game wrote before editing unlocks.
+func updateEditLock(bankWindow *BankWindow) {
+	locked := bankWindow.gameRunning()
+
+	banner := bankWindow.LockBanner
+	banner.RemoveAll()
+	if locked {
+		bannerLabel := widget.NewLabel("Elestrals Awakened is running. Editing is locked so the game doesn't overwrite your changes; it unlocks when the game exits.")
+		bannerLabel.Importance = widget.WarningImportance
+		bannerLabel.TextStyle = fyne.TextStyle{Bold: true}
+		bannerLabel.Wrapping = fyne.TextWrapWord
+		banner.Add(bannerLabel)
+	}
+	banner.Refresh()
+
+	session := bankWindow.Session
+	if session == nil || session.Locked == locked {
+		return
+	}
+	if !locked {
+		checkExternalChanges(bankWindow)
+	}
+	session.Locked = locked
+	session.changed()
+}
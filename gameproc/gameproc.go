@@ -0,0 +1,81 @@
+package gameproc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ExecutableName is the file name of the Elestrals Awakened playtest, as the
+// process shows up in the process list.
+const ExecutableName = "ElestralsAwakened-Playtest.exe"
+
+// IsRunning reports whether an Elestrals Awakened process is running,
+// however it was started.
+func IsRunning() (bool, error) {
+	names, err := processNames()
+	if err != nil {
+		return false, err
+	}
+	for _, name := range names {
+		if matches(name) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ErrRunning is returned by CheckClosed while the game is running.
+var ErrRunning = errors.New("Elestrals Awakened is running; close it first so it doesn't overwrite the change")
+
+// CheckClosed refuses changes to a save while the game runs, as the game would
+// write over them when it next saves. It returns ErrRunning if the game is
+// running, or the error from reading the process list if that can't be
+// checked, so a failed check never lets a change through.
+func CheckClosed() error {
+	running, err := IsRunning()
+	if err != nil {
+		return fmt.Errorf("error checking whether Elestrals Awakened is running: %w", err)
+	}
+	if running {
+		return ErrRunning
+	}
+	return nil
+}
+
+// matches is lenient about case and paths since the game may be listed by its
+// full path or, under Wine/Proton, as a Windows path on another platform.
+func matches(name string) bool {
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+		name = name[i+1:]
+	}
+	return strings.EqualFold(name, ExecutableName)
+}
+
+// Poll calls onChange with the result of IsRunning every interval, and once
+// straight away, whenever it differs from the last call. It stops when ctx is
+// done. onChange is called from a goroutine; errors are treated as not
+// running.
+func Poll(ctx context.Context, interval time.Duration, onChange func(running bool)) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		last, first := false, true
+		for {
+			running, _ := IsRunning()
+			if first || running != last {
+				onChange(running)
+				last, first = running, false
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
@@ -0,0 +1,29 @@
+//go:build linux
+package gameproc
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// processNames reads the command lines from /proc. The game runs through
+// Proton there, so its name is one of the arguments rather than the comm
+// name; each argument is returned as a name of its own.
+func processNames() ([]string, error) {
+	cmdlines, err := filepath.Glob("/proc/[0-9]*/cmdline")
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, path := range cmdlines {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			// The process exited while we were looking.
+			continue
+		}
+		names = append(names, strings.Split(strings.TrimRight(string(data), "\x00"), "\x00")...)
+	}
+	return names, nil
+}
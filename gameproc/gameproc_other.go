@@ -0,0 +1,22 @@
+//go:build !windows && !linux
+package gameproc
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// processNames lists the arguments of every process. Under CrossOver or Wine
+// the command is the Wine loader and the game's path is one of its
+// arguments, so each argument is returned as a name of its own, as on Linux.
+func processNames() ([]string, error) {
+	out, err := exec.Command("ps", "-axo", "args").Output()
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, line := range strings.Split(string(out), "\n") {
+		names = append(names, strings.Fields(line)...)
+	}
+	return names, nil
+}
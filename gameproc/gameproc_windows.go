@@ -0,0 +1,33 @@
+//go:build windows
+package gameproc
+
+import (
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+func processNames() ([]string, error) {
+	snapshot, err := windows.CreateToolhelp32Snapshot(windows.TH32CS_SNAPPROCESS, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer windows.CloseHandle(snapshot)
+
+	var entry windows.ProcessEntry32
+	entry.Size = uint32(unsafe.Sizeof(entry))
+	if err := windows.Process32First(snapshot, &entry); err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for {
+		names = append(names, windows.UTF16ToString(entry.ExeFile[:]))
+		if err := windows.Process32Next(snapshot, &entry); err != nil {
+			if err == windows.ERROR_NO_MORE_FILES {
+				return names, nil
+			}
+			return names, err
+		}
+	}
+}
@@ -0,0 +1,248 @@
+// Package journal replaces several files as one transaction. What every file
+// held before and will hold after is written to a journal first, so a
+// transaction cut short by a crash or power loss can be finished or undone
+// the next time the journal is opened.
+package journal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"pandorasbank/atomicfile"
+)
+
+// Write is one file a transaction replaces. Verify may be nil.
+type Write struct {
+	Path   string
+	Data   []byte
+	Verify func(data []byte) error
+}
+
+// File is one file of a journaled transaction.
+type File struct {
+	Path string `json:"path"`
+	// Existed is false when the transaction creates the file, so undoing it
+	// removes the file again.
+	Existed bool   `json:"existed"`
+	Before  []byte `json:"before"`
+	After   []byte `json:"after"`
+}
+
+// Transaction is what the journal holds while files are being replaced.
+type Transaction struct {
+	Action  string    `json:"action"`
+	Started time.Time `json:"started"`
+	Files   []File    `json:"files"`
+}
+
+// fileState is where a file of an unfinished transaction got to.
+type fileState int
+
+const (
+	stateBefore fileState = iota
+	stateAfter
+	// stateChanged is a file something else wrote since, which holds neither
+	// what it held before nor what the transaction wrote.
+	stateChanged
+)
+
+func (file File) state() (fileState, error) {
+	data, err := os.ReadFile(file.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		if !file.Existed {
+			return stateBefore, nil
+		}
+		return stateChanged, nil
+	}
+	if err != nil {
+		return stateChanged, err
+	}
+	switch {
+	case bytes.Equal(data, file.After):
+		return stateAfter, nil
+	case file.Existed && bytes.Equal(data, file.Before):
+		return stateBefore, nil
+	default:
+		return stateChanged, nil
+	}
+}
+
+// Outcome is what Recover did with an unfinished transaction.
+type Outcome int
+
+const (
+	// NothingToRecover means the last transaction completed.
+	NothingToRecover Outcome = iota
+	// Finished means the files the transaction hadn't replaced yet were
+	// written, or it turned out they all had been.
+	Finished
+	// RolledBack means the files were put back as they were before it,
+	// because something else had changed one of them since.
+	RolledBack
+)
+
+// ErrPending is returned by Commit while an earlier transaction is still in
+// the journal.
+var ErrPending = errors.New("an earlier transfer didn't finish and has to be recovered first")
+
+// Journal is the write-ahead journal at Path. It only exists while a
+// transaction is in progress or after one was interrupted.
+type Journal struct {
+	Path string
+}
+
+func New(path string) *Journal {
+	return &Journal{Path: path}
+}
+
+// Pending returns the transaction left in the journal, or nil if there is
+// none.
+func (j *Journal) Pending() (*Transaction, error) {
+	data, err := os.ReadFile(j.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading transfer journal: %w", err)
+	}
+	var tx Transaction
+	if err := json.Unmarshal(data, &tx); err != nil {
+		return nil, fmt.Errorf("error reading transfer journal: %w", err)
+	}
+	return &tx, nil
+}
+
+// Commit replaces every file in writes, or none of them. The journal is
+// written before any file is touched and removed once all of them are, so if
+// the process dies in between, Recover can finish the job. If a write fails
+// the files already replaced are put back.
+func (j *Journal) Commit(action string, writes []Write) error {
+	if pending, err := j.Pending(); err != nil {
+		return err
+	} else if pending != nil {
+		return ErrPending
+	}
+
+	tx := Transaction{Action: action, Started: time.Now()}
+	for _, write := range writes {
+		if write.Verify != nil {
+			if err := write.Verify(write.Data); err != nil {
+				return fmt.Errorf("refusing to write invalid %s: %w", filepath.Base(write.Path), err)
+			}
+		}
+		before, err := os.ReadFile(write.Path)
+		existed := err == nil
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("error reading current %s: %w", filepath.Base(write.Path), err)
+		}
+		tx.Files = append(tx.Files, File{Path: write.Path, Existed: existed, Before: before, After: write.Data})
+	}
+
+	if err := j.write(&tx); err != nil {
+		return err
+	}
+
+	for i, write := range writes {
+		if err := atomicfile.WriteFile(write.Path, write.Data, 0644, write.Verify); err != nil {
+			// atomicfile already put this one back; undo the ones before it.
+			if undoErr := undo(tx.Files[:i]); undoErr != nil {
+				return fmt.Errorf("%w\n\nThe files already written could not be put back (%v); they will be recovered on the next start.", err, undoErr)
+			}
+			if clearErr := j.clear(); clearErr != nil {
+				return fmt.Errorf("%w\n\nThe transfer journal could not be removed: %v", err, clearErr)
+			}
+			return err
+		}
+	}
+
+	return j.clear()
+}
+
+// Recover finishes or rolls back a transaction left in the journal. A
+// transaction is finished when every file still holds either its old or its
+// new contents. If something else wrote one of them since, the others are
+// rolled back instead and the changed file is left alone, since it was
+// written from the state before the transaction.
+func (j *Journal) Recover() (*Transaction, Outcome, error) {
+	tx, err := j.Pending()
+	if err != nil || tx == nil {
+		return nil, NothingToRecover, err
+	}
+
+	outcome := Finished
+	states := make([]fileState, len(tx.Files))
+	for i, file := range tx.Files {
+		if states[i], err = file.state(); err != nil {
+			return tx, NothingToRecover, fmt.Errorf("error reading %s: %w", filepath.Base(file.Path), err)
+		}
+		if states[i] == stateChanged {
+			outcome = RolledBack
+		}
+	}
+
+	if outcome == Finished {
+		for i, file := range tx.Files {
+			if states[i] == stateAfter {
+				continue
+			}
+			if err := atomicfile.WriteFile(file.Path, file.After, 0644, nil); err != nil {
+				return tx, NothingToRecover, err
+			}
+		}
+	} else if err := undo(tx.Files); err != nil {
+		return tx, NothingToRecover, err
+	}
+
+	return tx, outcome, j.clear()
+}
+
+// undo puts back the files that hold what the transaction wrote.
+func undo(files []File) error {
+	var failed []string
+	for _, file := range files {
+		state, err := file.state()
+		if err != nil || state != stateAfter {
+			continue
+		}
+		if file.Existed {
+			err = atomicfile.WriteFile(file.Path, file.Before, 0644, nil)
+		} else {
+			err = os.Remove(file.Path)
+		}
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", filepath.Base(file.Path), err))
+		}
+	}
+	if len(failed) > 0 {
+		return errors.New(strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+func (j *Journal) write(tx *Transaction) error {
+	data, err := json.Marshal(tx)
+	if err != nil {
+		return fmt.Errorf("error encoding transfer journal: %w", err)
+	}
+	err = atomicfile.WriteFile(j.Path, data, 0644, func(data []byte) error {
+		var check Transaction
+		return json.Unmarshal(data, &check)
+	})
+	if err != nil {
+		return fmt.Errorf("error writing transfer journal: %w", err)
+	}
+	return nil
+}
+
+func (j *Journal) clear() error {
+	if err := os.Remove(j.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("error removing transfer journal: %w", err)
+	}
+	return nil
+}
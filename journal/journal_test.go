@@ -0,0 +1,224 @@
+package journal
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fileCase is one file of a transaction left in the journal: what it held
+// before, what the transaction writes, and what is on disk when Recover runs.
+// A nil before means the transaction creates the file; a nil onDisk means it
+// doesn't exist.
+type fileCase struct {
+	before []byte
+	after  []byte
+	onDisk []byte
+	// want is what the file holds once Recover is done, nil for removed.
+	want []byte
+}
+
+func TestRecover(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   []fileCase
+		outcome Outcome
+	}{
+		{
+			name: "interrupted before any file was written",
+			files: []fileCase{
+				{before: []byte("save 1"), after: []byte("save 2"), onDisk: []byte("save 1"), want: []byte("save 2")},
+				{before: []byte("bank 1"), after: []byte("bank 2"), onDisk: []byte("bank 1"), want: []byte("bank 2")},
+			},
+			outcome: Finished,
+		},
+		{
+			name: "interrupted between files",
+			files: []fileCase{
+				{before: []byte("save 1"), after: []byte("save 2"), onDisk: []byte("save 2"), want: []byte("save 2")},
+				{before: []byte("bank 1"), after: []byte("bank 2"), onDisk: []byte("bank 1"), want: []byte("bank 2")},
+			},
+			outcome: Finished,
+		},
+		{
+			name: "interrupted before the journal was removed",
+			files: []fileCase{
+				{before: []byte("save 1"), after: []byte("save 2"), onDisk: []byte("save 2"), want: []byte("save 2")},
+				{before: []byte("bank 1"), after: []byte("bank 2"), onDisk: []byte("bank 2"), want: []byte("bank 2")},
+			},
+			outcome: Finished,
+		},
+		{
+			name: "file the transaction creates is written",
+			files: []fileCase{
+				{before: []byte("save 1"), after: []byte("save 2"), onDisk: []byte("save 2"), want: []byte("save 2")},
+				{before: nil, after: []byte("bank 1"), onDisk: nil, want: []byte("bank 1")},
+			},
+			outcome: Finished,
+		},
+		{
+			name: "file changed since is left alone and the rest rolled back",
+			files: []fileCase{
+				{before: []byte("save 1"), after: []byte("save 2"), onDisk: []byte("save 3"), want: []byte("save 3")},
+				{before: []byte("bank 1"), after: []byte("bank 2"), onDisk: []byte("bank 2"), want: []byte("bank 1")},
+			},
+			outcome: RolledBack,
+		},
+		{
+			name: "rolling back removes a file the transaction created",
+			files: []fileCase{
+				{before: []byte("save 1"), after: []byte("save 2"), onDisk: []byte("save 3"), want: []byte("save 3")},
+				{before: nil, after: []byte("bank 1"), onDisk: []byte("bank 1"), want: nil},
+			},
+			outcome: RolledBack,
+		},
+		{
+			name: "file removed since counts as changed",
+			files: []fileCase{
+				{before: []byte("save 1"), after: []byte("save 2"), onDisk: nil, want: nil},
+				{before: []byte("bank 1"), after: []byte("bank 2"), onDisk: []byte("bank 2"), want: []byte("bank 1")},
+			},
+			outcome: RolledBack,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			j := New(filepath.Join(dir, "journal.json"))
+
+			tx := Transaction{Action: test.name, Started: time.Now()}
+			for i, file := range test.files {
+				path := filepath.Join(dir, string(rune('a'+i)))
+				tx.Files = append(tx.Files, File{Path: path, Existed: file.before != nil, Before: file.before, After: file.after})
+				if file.onDisk != nil {
+					writeFile(t, path, file.onDisk)
+				}
+			}
+			if err := j.write(&tx); err != nil {
+				t.Fatal(err)
+			}
+
+			recovered, outcome, err := j.Recover()
+			if err != nil {
+				t.Fatalf("Recover: %v", err)
+			}
+			if outcome != test.outcome {
+				t.Errorf("outcome = %v, want %v", outcome, test.outcome)
+			}
+			if recovered == nil || recovered.Action != test.name {
+				t.Errorf("recovered transaction = %+v, want %q", recovered, test.name)
+			}
+			for i, file := range test.files {
+				checkFile(t, tx.Files[i].Path, file.want)
+			}
+			if pending, err := j.Pending(); err != nil || pending != nil {
+				t.Errorf("journal still pending after Recover: %+v, %v", pending, err)
+			}
+		})
+	}
+}
+
+func TestRecoverNothingPending(t *testing.T) {
+	j := New(filepath.Join(t.TempDir(), "journal.json"))
+	tx, outcome, err := j.Recover()
+	if tx != nil || outcome != NothingToRecover || err != nil {
+		t.Errorf("Recover = %+v, %v, %v, want nothing to recover", tx, outcome, err)
+	}
+}
+
+func TestCommit(t *testing.T) {
+	refuse := func(data []byte) error { return errors.New("invalid") }
+
+	tests := []struct {
+		name    string
+		writes  func(dir string) []Write
+		wantErr bool
+		// want is what each write's file holds afterwards, nil for missing.
+		want [][]byte
+	}{
+		{
+			name: "writes every file",
+			writes: func(dir string) []Write {
+				return []Write{
+					{Path: filepath.Join(dir, "save"), Data: []byte("save 2")},
+					{Path: filepath.Join(dir, "bank"), Data: []byte("bank 1")},
+				}
+			},
+			want: [][]byte{[]byte("save 2"), []byte("bank 1")},
+		},
+		{
+			name: "writes nothing if one fails verification",
+			writes: func(dir string) []Write {
+				return []Write{
+					{Path: filepath.Join(dir, "save"), Data: []byte("save 2")},
+					{Path: filepath.Join(dir, "bank"), Data: []byte("bank 1"), Verify: refuse},
+				}
+			},
+			wantErr: true,
+			want:    [][]byte{[]byte("save 1"), nil},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFile(t, filepath.Join(dir, "save"), []byte("save 1"))
+			j := New(filepath.Join(dir, "journal.json"))
+
+			writes := test.writes(dir)
+			err := j.Commit(test.name, writes)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("Commit error = %v, want error %v", err, test.wantErr)
+			}
+			for i, write := range writes {
+				checkFile(t, write.Path, test.want[i])
+			}
+			if _, err := os.Stat(j.Path); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("journal left behind: %v", err)
+			}
+		})
+	}
+}
+
+func TestCommitRefusedWhilePending(t *testing.T) {
+	dir := t.TempDir()
+	j := New(filepath.Join(dir, "journal.json"))
+	if err := j.write(&Transaction{Action: "interrupted"}); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "save")
+	if err := j.Commit("next", []Write{{Path: path, Data: []byte("save")}}); !errors.Is(err, ErrPending) {
+		t.Errorf("Commit error = %v, want ErrPending", err)
+	}
+	checkFile(t, path, nil)
+}
+
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// checkFile fails t unless path holds want, or doesn't exist if want is nil.
+func checkFile(t *testing.T, path string, want []byte) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if want == nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("%s exists (%q), want it missing", filepath.Base(path), data)
+		}
+		return
+	}
+	if err != nil {
+		t.Errorf("reading %s: %v", filepath.Base(path), err)
+		return
+	}
+	if string(data) != string(want) {
+		t.Errorf("%s = %q, want %q", filepath.Base(path), data, want)
+	}
+}
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -13,122 +15,32 @@ import (
 	"fyne.io/fyne/v2/app"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/driver/desktop"
 	"fyne.io/fyne/v2/storage"
 	"fyne.io/fyne/v2/widget"
 
+	"pandorasbank/activity"
+	"pandorasbank/archive"
+	"pandorasbank/atomicfile"
+	"pandorasbank/backup"
+	"pandorasbank/cli"
+	"pandorasbank/filewatch"
+	"pandorasbank/gameproc"
+	"pandorasbank/journal"
 	"pandorasbank/quickstart"
+	"pandorasbank/savedata"
 	"pandorasbank/steam"
 )
 
-type CombatPos struct {
-	X float64 `json:"x"`
-	Y float64 `json:"y"`
-	Z float64 `json:"z"`
-}
-
-type StatStages struct {
-	PhysicalAttack  int `json:"physicalAttack"`
-	AstralAttack    int `json:"astralAttack"`
-	PhysicalDefense int `json:"physicalDefense"`
-	AstralDefense   int `json:"astralDefense"`
-	Speed           int `json:"speed"`
-	Accuracy        int `json:"accuracy"`
-	Evasion         int `json:"evasion"`
-}
-
-type Elestral struct {
-	ID struct {
-		SerializedVersion string `json:"serializedVersion"`
-		Hash              string `json:"Hash"`
-	} `json:"id"`
-	Name                        string     `json:"name"`
-	Species                     string     `json:"species"`
-	UsesStellarMaterial         bool       `json:"usesStellarMaterial"`
-	Element                     int        `json:"element"`
-	SubElement                  int        `json:"subElement"`
-	HealthBaseStat              int        `json:"healthBaseStat"`
-	PhysicalAttack              int        `json:"physicalAttack"`
-	SpecialAttack               int        `json:"specialAttack"`
-	PhysicalDefense             int        `json:"physicalDefense"`
-	SpecialDefense              int        `json:"specialDefense"`
-	Speed                       int        `json:"speed"`
-	Ability0Name                string     `json:"Ability0Name"`
-	Ability1Name                string     `json:"Ability1Name"`
-	Ability2Name                string     `json:"Ability2Name"`
-	Ability3Name                string     `json:"Ability3Name"`
-	EmpoweredAbilityName        string     `json:"empoweredAbilityName"`
-	CurrentLevel                int        `json:"currentLevel"`
-	Health                      int        `json:"health"`
-	MaxHealth                   int        `json:"maxHealth"`
-	IsActiveCombat              bool       `json:"isActiveInCombat"`
-	IsDodgeEnabled              bool       `json:"isDodgeEnabled"`
-	IsCaster                    bool       `json:"isCaster"`
-	IsStellar                   bool       `json:"isStellar"`
-	TeamSlot                    int        `json:"teamSlot"`
-	AbilityHitIndex             int        `json:"abilityHitIndex"`
-	BondMeter                   int        `json:"bondMeter"`
-	TurnOrderUIIndex            int        `json:"turnOrderUIIndex"`
-	SelectedAbilityIndex        int        `json:"selectedAbilityIndex"`
-	ShouldSkipTurn              bool       `json:"shouldSkipTurn"`
-	LastDodgeTime               float64    `json:"lastDodgeTime"`
-	LastSuccessfulDodgeTime     float64    `json:"lastSuccessfulDodgeTime"`
-	CombatPos                   CombatPos  `json:"CombatPos"`
-	CharacterType               int        `json:"characterType"`
-	StatStages                  StatStages `json:"statStages"`
-	SuppressedSlots             int        `json:"suppressedSlots"`
-	SlotsUsedThisBattle         int        `json:"slotsUsedThisBattle"`
-	IncomingDamageMultiplier    float64    `json:"IncomingDamageMultiplier"`
-	OutgoingDamageMultiplier    float64    `json:"OutgoingDamageMultiplier"`
-	MovesPerformedSinceLastSwap int        `json:"MovesPerformedSinceLastSwap"`
-	LastUsedAbilitySlot         int        `json:"lastUsedAbilitySlot"`
-	HasUsedEmpoweredAbility     bool       `json:"hasUsedEmpoweredAbility"`
-}
-
-type PlayerData struct {
-	Name            string    `json:"Name"`
-	SpiritElement   int       `json:"SpiritElement"`
-	IsMaleCharacter bool      `json:"isMaleCharacter"`
-	Money           int       `json:"Money"`
-	FocusedSlot     int       `json:"FocusedSlot"`
-	Character0      *Elestral `json:"Character0"`
-	Character1      *Elestral `json:"Character1"`
-	Character2      *Elestral `json:"Character2"`
-	Character3      *Elestral `json:"Character3"`
-	MaxSp           int       `json:"MaxCasterSP"`
-	CurrentSp       int       `json:"CasterSP"`
-	BondMeter       int       `json:"BondMeter"`
-}
-
-type StorageEntry struct {
-	CharacterData *Elestral `json:"CharacterData"`
-}
-
-type StorageBox struct {
-	Entries []StorageEntry `json:"entries"`
-}
-
-type ActiveBoons struct {
-	ActiveBoonNames []string `json:"ActiveBoonNames"`
-	BoonUsageCounts []int    `json:"BoonUsageCounts"`
-}
-
-type GameSave struct {
-	ActivePlayerData PlayerData   `json:"activePlayerData"`
-	StorageBoxes     []StorageBox `json:"storageBoxes"`
-	GameFlags        []string     `json:"gameFlags"`
-	ActiveBoons      ActiveBoons  `json:"activeBoons"`
-	CurrentSceneName string       `json:"currentSceneName"`
-	SaveVersion      string       `json:"saveVersion"`
-	SaveTimestamp    string       `json:"saveTimestamp"`
-}
-
 type Settings struct {
-	CustomSavePath string `json:"customSavePath"`
-	CustomGamePath string `json:"customGamePath"`
-}
-
-type Bank struct {
-	Elestrals []*Elestral `json:"elestrals"`
+	CustomSavePath        string         `json:"customSavePath"`
+	CustomGamePath        string         `json:"customGamePath"`
+	BackupPolicy          *backup.Policy `json:"backupPolicy,omitempty"`
+	BackupIntervalMinutes int            `json:"backupIntervalMinutes,omitempty"`
+	StagedEditing         bool           `json:"stagedEditing,omitempty"`
+	// LastImportBox is the storage box the last bank import went to, zero
+	// based, which the next import offers first.
+	LastImportBox int `json:"lastImportBox,omitempty"`
 }
 
 type BankWindow struct {
@@ -141,36 +53,59 @@ type BankWindow struct {
 	TeamTab *container.TabItem
 	StorageTab *container.TabItem
 	BankTab *container.TabItem
+
+	Settings *Settings
+	// Boxes are the names and colours given to the storage boxes of each save.
+	Boxes *boxStore
+	Backups *backup.Manager
+	// Journal makes each write of the save and bank one transaction.
+	Journal *journal.Journal
+	// Activity records every change made to the save and bank.
+	Activity *activity.Log
+	// refreshActivity rereads the activity log while its window is open.
+	refreshActivity func()
+
+	// Session is the open save, nil on the welcome screen. EditMode is how
+	// saves opened from now on write their edits.
+	Session      *saveSession
+	EditMode     editMode
+	HistoryList  *widget.List
+	PendingPanel *fyne.Container
+	// ValidationPanel lists the open save's problems while Check Save is open.
+	ValidationPanel *fyne.Container
+
+	// Watcher reloads the open save and bank when something else writes them.
+	Watcher       *filewatch.Watcher
+	conflictShown bool
+
+	// LockBanner explains why editing is locked while the game runs, either
+	// launched from here or found in the process list.
+	LockBanner         *fyne.Container
+	gameLaunched       bool
+	gameProcessRunning bool
+	// stopBackupTimer stops the timed snapshots taken while the game runs.
+	stopBackupTimer func()
 }
 
-func getElementName(element int) string {
-	elements := map[int]string{
-		0: "N/A",
-		1: "Earth",
-		2: "Fire",
-		3: "Water",
-		4: "Thunder",
-		5: "Wind",
-		6: "Frost",
-		7: "Solar",
-		8: "Lunar",
-	}
-	if name, ok := elements[element]; ok {
-		return name
-	}
-	return fmt.Sprintf("Unknown (%d)", element)
+// elestralActions are the buttons an Elestral's card offers besides Edit.
+// Actions left nil get no button.
+type elestralActions struct {
+	OnExport        func()
+	OnImport        func()
+	OnRelease       func()
+	OnMoveToParty   func()
+	OnMoveToStorage func()
+	OnMoveInStorage func()
+	OnMoveUp        func()
+	OnMoveDown      func()
+	PartyFull       bool
 }
 
-func createElestralCard(e *Elestral, onSave func(), onExport func(), onImport func(), onRelease func(), onMoveToParty func(), partyFull bool) *widget.Card {
-	if e == nil || e.Species == "" {
+func createElestralCard(e *savedata.Elestral, onSave func(action string), actions elestralActions, readOnly bool) *widget.Card {
+	if e.Empty() {
 		return nil
 	}
 
-	stellar := ""
-	if e.IsStellar {
-		stellar = " (Stellar)"
-	}
-
 	nameLabel := widget.NewLabel(e.Name)
 	nameLabel.TextStyle = fyne.TextStyle{Bold: true}
 	editButton := widget.NewButton("Edit", func() {
@@ -179,57 +114,115 @@ func createElestralCard(e *Elestral, onSave func(), onExport func(), onImport fu
 		dialog.ShowCustomConfirm("Edit Name", "Save", "Cancel",
 			nameEntry,
 			func(save bool) {
-				if save && nameEntry.Text != "" {
-					e.Name = nameEntry.Text
+				if save && nameEntry.Text != e.Name {
+					action := fmt.Sprintf("Rename %s to %s", e.Name, nameEntry.Text)
+					if err := savedata.Rename(e, nameEntry.Text); err != nil {
+						dialog.ShowError(err, fyne.CurrentApp().Driver().AllWindows()[0])
+						return
+					}
 					nameLabel.SetText(e.Name)
 					if onSave != nil {
-						onSave()
+						onSave(action)
 					}
 				}
 			}, fyne.CurrentApp().Driver().AllWindows()[0])
 	})
 
 	nameContainerItems := []fyne.CanvasObject{nameLabel, editButton}
-	if onExport != nil {
+	if actions.OnExport != nil {
 		exportBtn := widget.NewButton("Export to Bank", func() {
-			onExport()
+			actions.OnExport()
 		})
 		nameContainerItems = append(nameContainerItems, exportBtn)
 	}
 
-	if onImport != nil {
+	if actions.OnImport != nil {
 		importBtn := widget.NewButton("Import to Storage", func() {
-			onImport()
+			actions.OnImport()
 		})
 		nameContainerItems = append(nameContainerItems, importBtn)
 	}
 
-	if onMoveToParty != nil {
-		moveToPartyBtn := widget.NewButton("Move to Party", func() {
-			onMoveToParty()
-		})
-		if partyFull {
-			moveToPartyBtn.Disable()
+	if actions.OnMoveToParty != nil {
+		label := "Move to Party"
+		if actions.PartyFull {
+			label = "Swap into Party..."
 		}
+		moveToPartyBtn := widget.NewButton(label, func() {
+			actions.OnMoveToParty()
+		})
 		nameContainerItems = append(nameContainerItems, moveToPartyBtn)
 	}
 
-	if onRelease != nil {
+	if actions.OnMoveToStorage != nil {
+		moveToStorageBtn := widget.NewButton("Move to Storage", func() {
+			actions.OnMoveToStorage()
+		})
+		nameContainerItems = append(nameContainerItems, moveToStorageBtn)
+	}
+
+	if actions.OnMoveInStorage != nil {
+		moveInStorageBtn := widget.NewButton("Move...", func() {
+			actions.OnMoveInStorage()
+		})
+		nameContainerItems = append(nameContainerItems, moveInStorageBtn)
+	}
+
+	if actions.OnMoveUp != nil || actions.OnMoveDown != nil {
+		upBtn := widget.NewButton("Up", func() {
+			actions.OnMoveUp()
+		})
+		if actions.OnMoveUp == nil {
+			upBtn.Disable()
+		}
+		downBtn := widget.NewButton("Down", func() {
+			actions.OnMoveDown()
+		})
+		if actions.OnMoveDown == nil {
+			downBtn.Disable()
+		}
+		nameContainerItems = append(nameContainerItems, upBtn, downBtn)
+	}
+
+	if actions.OnRelease != nil {
 		releaseBtn := widget.NewButton("Release", func() {
-			onRelease()
+			actions.OnRelease()
 		})
 		nameContainerItems = append(nameContainerItems, releaseBtn)
 	}
 
+	if readOnly {
+		for _, item := range nameContainerItems {
+			if button, ok := item.(*widget.Button); ok {
+				button.Disable()
+			}
+		}
+	}
+
 	nameContainer := container.NewHBox(nameContainerItems...)
+	infoLabel := widget.NewLabel(elestralSummary(e))
+
+	contentItems := []fyne.CanvasObject{nameContainer, infoLabel}
+
+	content := container.NewVBox(contentItems...)
+
+	return widget.NewCard("", "", content)
+}
+
+func elestralSummary(e *savedata.Elestral) string {
+	stellar := ""
+	if e.IsStellar {
+		stellar = " (Stellar)"
+	}
+
 	speciesInfo := e.Species + stellar
-	infoLabel := widget.NewLabel(fmt.Sprintf(
+	return fmt.Sprintf(
 		`%s | %s/%s | Lvl %d | HP %d/%d
 Atk %d/%d | Def %d/%d | Spd %d
 %s, %s, %s, %s | Emp: %s`,
 		speciesInfo,
-		getElementName(e.Element),
-		getElementName(e.SubElement),
+		savedata.ElementName(e.Element),
+		savedata.ElementName(e.SubElement),
 		e.CurrentLevel,
 		e.Health,
 		e.MaxHealth,
@@ -243,18 +236,12 @@ Atk %d/%d | Def %d/%d | Spd %d
 		e.Ability2Name,
 		e.Ability3Name,
 		e.EmpoweredAbilityName,
-	))
-
-	contentItems := []fyne.CanvasObject{nameContainer, infoLabel}
-
-	content := container.NewVBox(contentItems...)
-
-	return widget.NewCard("", "", content)
+	)
 }
 
-func createPlayerInfoCard(gameSave *GameSave, onSave func()) *widget.Card {
+func createPlayerInfoCard(gameSave *savedata.GameSave, onSave func(action string), readOnly bool) *widget.Card {
 	nameLabel := widget.NewLabel(fmt.Sprintf("Name: %s", gameSave.ActivePlayerData.Name))
-	elementLabel := widget.NewLabel(fmt.Sprintf("Spirit Element: %s", getElementName(gameSave.ActivePlayerData.SpiritElement)))
+	elementLabel := widget.NewLabel(fmt.Sprintf("Spirit Element: %s", savedata.ElementName(gameSave.ActivePlayerData.SpiritElement)))
 
 	genderOptions := []string{"Male", "Female"}
 	selectedGender := "Male"
@@ -265,10 +252,13 @@ func createPlayerInfoCard(gameSave *GameSave, onSave func()) *widget.Card {
 	genderSelect := widget.NewSelect(genderOptions, func(selected string) {
 		gameSave.ActivePlayerData.IsMaleCharacter = (selected == "Male")
 		if onSave != nil {
-			onSave()
+			onSave("Change gender to " + selected)
 		}
 	})
 	genderSelect.SetSelected(selectedGender)
+	if readOnly {
+		genderSelect.Disable()
+	}
 
 	genderContainer := container.NewHBox(
 		widget.NewLabel("Gender:"),
@@ -284,36 +274,74 @@ func createPlayerInfoCard(gameSave *GameSave, onSave func()) *widget.Card {
 	return widget.NewCard("Player Info", "", cardContent)
 }
 
-func createTeamTab(gameSave *GameSave, bank *Bank, onSave func(), onBankUpdate func(), myWindow fyne.Window) fyne.CanvasObject {
-	elestrals := []*Elestral{
-		gameSave.ActivePlayerData.Character0,
-		gameSave.ActivePlayerData.Character1,
-		gameSave.ActivePlayerData.Character2,
-		gameSave.ActivePlayerData.Character3,
+func createTeamTab(gameSave *savedata.GameSave, bank *savedata.Bank, boxes saveBoxes, onChange func(action string), readOnly bool, myWindow fyne.Window) fyne.CanvasObject {
+	elestrals := gameSave.ActivePlayerData.Party()
+	// A save can leave gaps in the party, so Up and Down move past them to the
+	// next member rather than the next slot.
+	var members []int
+	for i, e := range elestrals {
+		if !e.Empty() {
+			members = append(members, i)
+		}
 	}
 
 	var cards []fyne.CanvasObject
-	playerInfo := createPlayerInfoCard(gameSave, onSave)
+	playerInfo := createPlayerInfoCard(gameSave, onChange, readOnly)
 	cards = append(cards, playerInfo)
 
-	for _, e := range elestrals {
-		elestral := e
+	for n, i := range members {
+		elestral := elestrals[i]
+		from := savedata.AtParty(i)
 		onExport := func() {
-			if elestral != nil && elestral.Species != "" {
-				elesCopy := *elestral
-				bank.Elestrals = append(bank.Elestrals, &elesCopy)
-				if onBankUpdate != nil {
-					onBankUpdate()
+			elestralName := elestral.Name
+			if err := savedata.ExportToBank(gameSave, bank, from); err != nil {
+				dialog.ShowError(err, myWindow)
+				return
+			}
+			if onChange != nil {
+				onChange(fmt.Sprintf("Export %s to bank", elestralName))
+			}
+			dialog.ShowInformation("Export Successful",
+				fmt.Sprintf("%s has been exported to the bank!", elestralName), myWindow)
+		}
+
+		onMoveToStorage := func() {
+			elestralName := elestral.Name
+			pickStorageBox(gameSave, boxes, fmt.Sprintf("Move %s to Storage", elestralName), myWindow, func(box int) {
+				slot, err := savedata.MoveToBox(gameSave, bank, from, box)
+				if err != nil {
+					dialog.ShowError(err, myWindow)
+					return
 				}
-				*elestral = Elestral{}
-				if onSave != nil {
-					onSave()
+				if onChange != nil {
+					onChange(fmt.Sprintf("Move %s to %s", elestralName, slot))
+				}
+				dialog.ShowInformation("Move Successful",
+					fmt.Sprintf("%s has been moved to %s!", elestralName, boxes.Slot(slot)), myWindow)
+			})
+		}
+
+		actions := elestralActions{OnExport: onExport, OnMoveToStorage: onMoveToStorage}
+		swapWith := func(other int) func() {
+			return func() {
+				elestralName := elestral.Name
+				if err := savedata.Swap(gameSave, bank, from, savedata.AtParty(other)); err != nil {
+					dialog.ShowError(err, myWindow)
+					return
+				}
+				if onChange != nil {
+					onChange(fmt.Sprintf("Move %s to party slot %d", elestralName, other+1))
 				}
-				dialog.ShowInformation("Export Successful",
-					fmt.Sprintf("%s has been exported to the bank!", elestral.Name), myWindow)
 			}
 		}
-		if card := createElestralCard(e, onSave, onExport, nil, nil, nil, false); card != nil {
+		if n > 0 {
+			actions.OnMoveUp = swapWith(members[n-1])
+		}
+		if n < len(members)-1 {
+			actions.OnMoveDown = swapWith(members[n+1])
+		}
+
+		if card := createElestralCard(elestral, onChange, actions, readOnly); card != nil {
 			cards = append(cards, card)
 		}
 	}
@@ -322,116 +350,109 @@ func createTeamTab(gameSave *GameSave, bank *Bank, onSave func(), onBankUpdate f
 	return container.NewVScroll(content)
 }
 
-func createStorageTab(gameSave *GameSave, bank *Bank, onSave func(), onBankUpdate func(), myWindow fyne.Window) fyne.CanvasObject {
+// createStorageTab shows a tab per storage box. onEditBox names a box.
+func createStorageTab(gameSave *savedata.GameSave, bank *savedata.Bank, boxes saveBoxes, onEditBox func(box int), onChange func(action string), readOnly bool, myWindow fyne.Window) fyne.CanvasObject {
 	var boxTabs []*container.TabItem
 	for i, box := range gameSave.StorageBoxes {
 		var cards []fyne.CanvasObject
 
-		headerLabel := widget.NewLabel(fmt.Sprintf("Storage Box %d - %d Elestrals", i+1, len(box.Entries)))
+		boxIdx := i
+		header := fmt.Sprintf("Storage Box %d - %d Elestrals", i+1, len(box.Entries))
+		if name := boxes[i].Name; name != "" {
+			header = fmt.Sprintf("%s (Storage Box %d) - %d Elestrals", name, i+1, len(box.Entries))
+		}
+		headerLabel := widget.NewLabel(header)
 		headerLabel.TextStyle = fyne.TextStyle{Bold: true}
-		cards = append(cards, headerLabel)
-
-		// Check if party is full
-		partyFull := gameSave.ActivePlayerData.Character0 != nil && gameSave.ActivePlayerData.Character0.Species != "" &&
-			gameSave.ActivePlayerData.Character1 != nil && gameSave.ActivePlayerData.Character1.Species != "" &&
-			gameSave.ActivePlayerData.Character2 != nil && gameSave.ActivePlayerData.Character2.Species != "" &&
-			gameSave.ActivePlayerData.Character3 != nil && gameSave.ActivePlayerData.Character3.Species != ""
+		headerItems := []fyne.CanvasObject{headerLabel}
+		if icon := boxes.Icon(i); icon != nil {
+			headerItems = append([]fyne.CanvasObject{widget.NewIcon(icon)}, headerItems...)
+		}
+		if onEditBox != nil {
+			headerItems = append(headerItems, widget.NewButton("Edit Box...", func() {
+				onEditBox(boxIdx)
+			}))
+		}
+		cards = append(cards, container.NewHBox(headerItems...))
+		if description := boxes[i].Description; description != "" {
+			descriptionLabel := widget.NewLabel(description)
+			descriptionLabel.Wrapping = fyne.TextWrapWord
+			cards = append(cards, descriptionLabel)
+		}
+		hintLabel := widget.NewLabel("Drag an Elestral onto another entry to move or swap it, or use Move... to pick an entry in any box.")
+		hintLabel.Wrapping = fyne.TextWrapWord
+		cards = append(cards, hintLabel)
+
+		partyFull := savedata.PartyFull(gameSave)
+		var slotViews []*storageSlotView
+		onDrop := func(from, to savedata.StorageSlot) {
+			moveInStorage(gameSave, bank, from, to, onChange, myWindow)
+		}
 
-		for _, entry := range box.Entries {
+		for j, entry := range box.Entries {
 			elestral := entry.CharacterData
+			slot := savedata.StorageSlot{Box: i, Entry: j}
+			from := savedata.AtStorage(slot)
 			onExport := func() {
-				if elestral != nil && elestral.Species != "" {
-					elesCopy := *elestral
-					bank.Elestrals = append(bank.Elestrals, &elesCopy)
-					*entry.CharacterData = Elestral{}
-					if onSave != nil {
-						onSave()
-					}
-					if onBankUpdate != nil {
-						onBankUpdate()
-					}
-					dialog.ShowInformation("Export Successful",
-						fmt.Sprintf("%s has been exported to the bank!", elestral.Name), myWindow)
+				elestralName := elestral.Name
+				if err := savedata.ExportToBank(gameSave, bank, from); err != nil {
+					dialog.ShowError(err, myWindow)
+					return
+				}
+				if onChange != nil {
+					onChange(fmt.Sprintf("Export %s to bank", elestralName))
 				}
+				dialog.ShowInformation("Export Successful",
+					fmt.Sprintf("%s has been exported to the bank!", elestralName), myWindow)
 			}
 
 			onMoveToParty := func() {
-				if elestral != nil && elestral.Species != "" {
-					// Find first available party slot
-					var targetSlot *Elestral
-					slotName := ""
-					if gameSave.ActivePlayerData.Character0 == nil || gameSave.ActivePlayerData.Character0.Species == "" {
-						targetSlot = gameSave.ActivePlayerData.Character0
-						if targetSlot == nil {
-							targetSlot = &Elestral{}
-							gameSave.ActivePlayerData.Character0 = targetSlot
-						}
-						slotName = "Slot 1"
-					} else if gameSave.ActivePlayerData.Character1 == nil || gameSave.ActivePlayerData.Character1.Species == "" {
-						targetSlot = gameSave.ActivePlayerData.Character1
-						if targetSlot == nil {
-							targetSlot = &Elestral{}
-							gameSave.ActivePlayerData.Character1 = targetSlot
-						}
-						slotName = "Slot 2"
-					} else if gameSave.ActivePlayerData.Character2 == nil || gameSave.ActivePlayerData.Character2.Species == "" {
-						targetSlot = gameSave.ActivePlayerData.Character2
-						if targetSlot == nil {
-							targetSlot = &Elestral{}
-							gameSave.ActivePlayerData.Character2 = targetSlot
-						}
-						slotName = "Slot 3"
-					} else if gameSave.ActivePlayerData.Character3 == nil || gameSave.ActivePlayerData.Character3.Species == "" {
-						targetSlot = gameSave.ActivePlayerData.Character3
-						if targetSlot == nil {
-							targetSlot = &Elestral{}
-							gameSave.ActivePlayerData.Character3 = targetSlot
-						}
-						slotName = "Slot 4"
-					}
-
-					if targetSlot != nil {
-						elestralName := elestral.Name
-						*targetSlot = *elestral
-						*entry.CharacterData = Elestral{}
-						if onSave != nil {
-							onSave()
-						}
-						if onBankUpdate != nil {
-							onBankUpdate()
-						}
-						dialog.ShowInformation("Move Successful",
-							fmt.Sprintf("%s has been moved to party %s!", elestralName, slotName), myWindow)
-					}
+				elestralName := elestral.Name
+				if partyFull {
+					swapIntoParty(gameSave, bank, boxes, from, onChange, myWindow)
+					return
+				}
+				partySlot, err := savedata.MoveToParty(gameSave, bank, from)
+				if err != nil {
+					dialog.ShowError(err, myWindow)
+					return
+				}
+				slotName := fmt.Sprintf("Slot %d", partySlot+1)
+				if onChange != nil {
+					onChange(fmt.Sprintf("Move %s to party %s", elestralName, slotName))
 				}
+				dialog.ShowInformation("Move Successful",
+					fmt.Sprintf("%s has been moved to party %s!", elestralName, slotName), myWindow)
 			}
 
-			if card := createElestralCard(entry.CharacterData, onSave, onExport, nil, nil, onMoveToParty, partyFull); card != nil {
-				cards = append(cards, card)
+			onMoveInStorage := func() {
+				elestralName := elestral.Name
+				pickStorageEntry(gameSave, boxes, slot, fmt.Sprintf("Move %s", elestralName), myWindow, func(to savedata.StorageSlot) {
+					moveInStorage(gameSave, bank, slot, to, onChange, myWindow)
+				})
 			}
+
+			actions := elestralActions{OnExport: onExport, OnMoveToParty: onMoveToParty, OnMoveInStorage: onMoveInStorage, PartyFull: partyFull}
+			var slotContent fyne.CanvasObject
+			if card := createElestralCard(entry.CharacterData, onChange, actions, readOnly); card != nil {
+				slotContent = card
+			} else {
+				slotContent = widget.NewLabel(fmt.Sprintf("Entry %d - empty", j+1))
+			}
+			slotView := newStorageSlotView(slot, slotContent, !readOnly && !elestral.Empty(), &slotViews, onDrop)
+			slotViews = append(slotViews, slotView)
+			cards = append(cards, slotView)
 		}
 
 		content := container.NewVBox(cards...)
 		scrollContainer := container.NewVScroll(content)
-		boxTab := container.NewTabItem(fmt.Sprintf("Box %d", i+1), scrollContainer)
+		boxTab := container.NewTabItemWithIcon(boxes.Title(i), boxes.Icon(i), scrollContainer)
 		boxTabs = append(boxTabs, boxTab)
 	}
 
 	return container.NewAppTabs(boxTabs...)
 }
 
-func findFirstAvailableSlot(gameSave *GameSave) (int, int, bool) {
-	for boxIdx, box := range gameSave.StorageBoxes {
-		for entryIdx, entry := range box.Entries {
-			if entry.CharacterData == nil || entry.CharacterData.Species == "" {
-				return boxIdx, entryIdx, true
-			}
-		}
-	}
-	return -1, -1, false
-}
-
-func createBankTab(gameSave *GameSave, bank *Bank, onSave func(), onBankUpdate func(), myWindow fyne.Window) fyne.CanvasObject {
+func createBankTab(gameSave *savedata.GameSave, bank *savedata.Bank, boxes saveBoxes, settings *Settings, onChange func(action string), readOnly bool, myWindow fyne.Window) fyne.CanvasObject {
 	var cards []fyne.CanvasObject
 
 	headerLabel := widget.NewLabel(fmt.Sprintf("Elestral Bank - %d Elestrals", len(bank.Elestrals)))
@@ -443,37 +464,51 @@ func createBankTab(gameSave *GameSave, bank *Bank, onSave func(), onBankUpdate f
 		eles := elestral
 
 		onImport := func() {
-			boxIdx, entryIdx, found := findFirstAvailableSlot(gameSave)
-			if !found {
-				dialog.ShowError(fmt.Errorf("no available slots in storage boxes"), myWindow)
-				return
-			}
-
-			elesCopy := *eles
-			gameSave.StorageBoxes[boxIdx].Entries[entryIdx].CharacterData = &elesCopy
-			bank.Elestrals = append(bank.Elestrals[:index], bank.Elestrals[index+1:]...)
-			if onSave != nil {
-				onSave()
-			}
-			if onBankUpdate != nil {
-				onBankUpdate()
-			}
+			pickImportDestination(gameSave, boxes, settings, eles.Name, myWindow, func(to importDestination) {
+				var err error
+				var where, shown string
+				switch {
+				case to.Party:
+					var partySlot int
+					partySlot, err = savedata.MoveToParty(gameSave, bank, savedata.AtBank(index))
+					where = savedata.AtParty(partySlot).String()
+					shown = where
+				case to.Entry < 0:
+					var slot savedata.StorageSlot
+					slot, err = savedata.MoveToBox(gameSave, bank, savedata.AtBank(index), to.Box)
+					where, shown = slot.String(), boxes.Slot(slot)
+				default:
+					slot := savedata.StorageSlot{Box: to.Box, Entry: to.Entry}
+					err = savedata.Move(gameSave, bank, savedata.AtBank(index), savedata.AtStorage(slot))
+					where, shown = slot.String(), boxes.Slot(slot)
+				}
+				if err != nil {
+					dialog.ShowError(err, myWindow)
+					return
+				}
+				if onChange != nil {
+					onChange(fmt.Sprintf("Import %s to %s", eles.Name, where))
+				}
 
-			dialog.ShowInformation("Import Successful",
-				fmt.Sprintf("%s has been imported to Storage Box %d!", eles.Name, boxIdx+1), myWindow)
+				dialog.ShowInformation("Import Successful",
+					fmt.Sprintf("%s has been imported to %s!", eles.Name, shown), myWindow)
+			})
 		}
 
 		onRelease := func() {
 			dialog.ShowConfirm("Release Elestral",
-				fmt.Sprintf("Are you sure you want to release %s? This cannot be undone.", eles.Name),
+				fmt.Sprintf("Are you sure you want to release %s? This can be undone with Ctrl+Z until the save is closed.", eles.Name),
 				func(confirm bool) {
 					if !confirm {
 						return
 					}
 
-					bank.Elestrals = append(bank.Elestrals[:index], bank.Elestrals[index+1:]...)
-					if onBankUpdate != nil {
-						onBankUpdate()
+					if _, err := savedata.Release(bank, index); err != nil {
+						dialog.ShowError(err, myWindow)
+						return
+					}
+					if onChange != nil {
+						onChange(fmt.Sprintf("Release %s", eles.Name))
 					}
 
 					dialog.ShowInformation("Released",
@@ -481,7 +516,8 @@ func createBankTab(gameSave *GameSave, bank *Bank, onSave func(), onBankUpdate f
 				}, myWindow)
 		}
 
-		if card := createElestralCard(elestral, nil, nil, onImport, onRelease, nil, false); card != nil {
+		actions := elestralActions{OnImport: onImport, OnRelease: onRelease}
+		if card := createElestralCard(elestral, onChange, actions, readOnly); card != nil {
 			cards = append(cards, card)
 		}
 	}
@@ -496,6 +532,12 @@ func createBankTab(gameSave *GameSave, bank *Bank, onSave func(), onBankUpdate f
 	return container.NewVScroll(content)
 }
 
+// verifyDecodes checks that data written for a T still decodes as one.
+func verifyDecodes[T any](data []byte) error {
+	var v T
+	return json.Unmarshal(data, &v)
+}
+
 func getSettingsFilePath() (string, error) {
 	exePath, err := os.Getwd()
 	if err != nil {
@@ -538,7 +580,7 @@ func saveSettings(settings *Settings) error {
 		return err
 	}
 
-	return os.WriteFile(settingsPath, data, 0644)
+	return atomicfile.WriteFile(settingsPath, data, 0644, verifyDecodes[Settings])
 }
 
 func getBankFilePath() (string, error) {
@@ -550,40 +592,44 @@ func getBankFilePath() (string, error) {
 	return filepath.Join(exeDir, "pbank_store.json"), nil
 }
 
-func loadBank() (*Bank, error) {
+func loadBank() (*savedata.Bank, error) {
 	bankPath, err := getBankFilePath()
 	if err != nil {
-		return &Bank{Elestrals: []*Elestral{}}, nil
+		return savedata.NewBank(), nil
 	}
+	return savedata.LoadBank(bankPath)
+}
 
-	data, err := os.ReadFile(bankPath)
+// getJournalFilePath is where transfers between the save and bank are
+// journaled while they are written.
+func getJournalFilePath() (string, error) {
+	exePath, err := os.Executable()
 	if err != nil {
-		if os.IsNotExist(err) {
-			return &Bank{Elestrals: []*Elestral{}}, nil
-		}
-		return nil, err
-	}
-
-	var bank Bank
-	if err := json.Unmarshal(data, &bank); err != nil {
-		return nil, err
+		return "", err
 	}
-
-	return &bank, nil
+	exeDir := filepath.Dir(exePath)
+	return filepath.Join(exeDir, "pbank_journal.json"), nil
 }
 
-func saveBank(bank *Bank) error {
-	bankPath, err := getBankFilePath()
+// recoverTransfer finishes or rolls back a write of the save and bank that
+// was cut short the last time, before either is read.
+func recoverTransfer(transfers *journal.Journal, myWindow fyne.Window) {
+	tx, outcome, err := transfers.Recover()
 	if err != nil {
-		return err
+		dialog.ShowError(fmt.Errorf("error recovering an unfinished transfer: %w\n\nChanges can't be written until it is recovered.", err), myWindow)
+		return
 	}
 
-	data, err := json.MarshalIndent(bank, "", "    ")
-	if err != nil {
-		return err
+	switch outcome {
+	case journal.Finished:
+		dialog.ShowInformation("Transfer Recovered",
+			fmt.Sprintf("\"%s\" (%s) was interrupted before it was fully written. It has now been finished.",
+				tx.Action, tx.Started.Local().Format("2006-01-02 15:04:05")), myWindow)
+	case journal.RolledBack:
+		dialog.ShowInformation("Transfer Rolled Back",
+			fmt.Sprintf("\"%s\" (%s) was interrupted before it was fully written, and the save or bank has changed since. It has been undone so no Elestral is lost or duplicated.",
+				tx.Action, tx.Started.Local().Format("2006-01-02 15:04:05")), myWindow)
 	}
-
-	return os.WriteFile(bankPath, data, 0644)
 }
 
 func getDefaultSavePath(settings *Settings) string {
@@ -593,18 +639,8 @@ func getDefaultSavePath(settings *Settings) string {
 		}
 	}
 
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return ""
-	}
-
-	var savePath string
-	switch runtime.GOOS {
-	case "windows":
-		savePath = filepath.Join(homeDir, "AppData", "LocalLow", "DefaultCompany", "ElestralsAwakened-Playtest", "gamesave.json")
-	case "darwin":
-		savePath = filepath.Join(homeDir, "Library", "Application Support", "CrossOver", "Bottles", "Steam", "drive_c", "users", "crossover", "AppData", "LocalLow", "DefaultCompany", "ElestralsAwakened-Playtest", "gamesave.json")
-	default:
+	savePath := getStandardSavePath()
+	if savePath == "" {
 		return ""
 	}
 
@@ -615,37 +651,22 @@ func getDefaultSavePath(settings *Settings) string {
 	return ""
 }
 
-func loadGameSave(filePath string) (*GameSave, error) {
-	data, err := os.ReadFile(filePath)
-	if err != nil {
-		return nil, fmt.Errorf("error reading file: %w", err)
-	}
-
-	var gameSave GameSave
-	if err := json.Unmarshal(data, &gameSave); err != nil {
-		return nil, fmt.Errorf("error parsing JSON: %w", err)
-	}
-
-	return &gameSave, nil
-}
-
-func saveGameSave(filePath string, gameSave *GameSave) error {
-	if gameSave.ActivePlayerData.MaxSp == 0 {
-		gameSave.ActivePlayerData.MaxSp = 100
-		gameSave.ActivePlayerData.CurrentSp = 100
-		gameSave.ActivePlayerData.BondMeter = 3
-	}
-
-	data, err := json.MarshalIndent(gameSave, "", "    ")
+// getStandardSavePath is where the game keeps its save on this platform,
+// whether or not it exists yet.
+func getStandardSavePath() string {
+	homeDir, err := os.UserHomeDir()
 	if err != nil {
-		return fmt.Errorf("error marshaling JSON: %w", err)
+		return ""
 	}
 
-	if err := os.WriteFile(filePath, data, 0644); err != nil {
-		return fmt.Errorf("error writing file: %w", err)
+	switch runtime.GOOS {
+	case "windows":
+		return filepath.Join(homeDir, "AppData", "LocalLow", "DefaultCompany", "ElestralsAwakened-Playtest", "gamesave.json")
+	case "darwin":
+		return filepath.Join(homeDir, "Library", "Application Support", "CrossOver", "Bottles", "Steam", "drive_c", "users", "crossover", "AppData", "LocalLow", "DefaultCompany", "ElestralsAwakened-Playtest", "gamesave.json")
+	default:
+		return ""
 	}
-
-	return nil
 }
 
 func copyFile(src, dst string) error {
@@ -700,7 +721,8 @@ func backupSaveFile(sourcePath string, myWindow fyne.Window) {
 	saveDialog.Show()
 }
 
-func restoreSaveFile(destPath string, myWindow fyne.Window) {
+func restoreSaveFile(destPath string, bankWindow *BankWindow, onRestored func()) {
+	myWindow := bankWindow.Window
 	dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
 		if err != nil {
 			dialog.ShowError(err, myWindow)
@@ -712,22 +734,7 @@ func restoreSaveFile(destPath string, myWindow fyne.Window) {
 		defer reader.Close()
 
 		sourcePath := reader.URI().Path()
-
-		dialog.ShowConfirm("Confirm Restore",
-			fmt.Sprintf("This will overwrite your current save file at:\n%s\n\nAre you sure?", destPath),
-			func(confirm bool) {
-				if !confirm {
-					return
-				}
-
-				if err := copyFile(sourcePath, destPath); err != nil {
-					dialog.ShowError(err, myWindow)
-					return
-				}
-
-				dialog.ShowInformation("Restore Successful",
-					"Save file has been restored successfully!", myWindow)
-			}, myWindow)
+		confirmRestore(bankWindow, sourcePath, destPath, myWindow, onRestored)
 	}, myWindow)
 }
 
@@ -754,50 +761,121 @@ func changeDefaultSaveLocation(settings *Settings, myWindow fyne.Window) {
 	}, myWindow)
 }
 
-func displayGameSave(filePath string, bank *Bank, bankWindow *BankWindow) {
-	gameSave, err := loadGameSave(filePath)
-	if err != nil {
-		dialog.ShowError(err, bankWindow.Window)
-		return
+func displayGameSave(filePath string, bank *savedata.Bank, bankWindow *BankWindow) {
+	openGameSave(filePath, bank, bankWindow, bankWindow.EditMode)
+}
+
+func openGameSave(filePath string, bank *savedata.Bank, bankWindow *BankWindow, mode editMode) {
+	// Edits staged on the save being replaced would otherwise linger in the bank.
+	if bankWindow.Session != nil {
+		bankWindow.Session.Discard()
 	}
 
-	onSave := func() {
-		err := saveGameSave(filePath, gameSave)
-		if err != nil {
+	gameSave, err := savedata.LoadSave(filePath)
+	if err != nil {
+		if _, statErr := os.Stat(filePath); statErr == nil {
+			offerRepair(bankWindow, bank, filePath, err)
+		} else {
 			dialog.ShowError(err, bankWindow.Window)
 		}
+		return
 	}
 
-	var onBankUpdate func()
-	onBankUpdate = func() {
-		err := saveBank(bank)
-		if err != nil {
-			dialog.ShowError(fmt.Errorf("error saving bank: %w", err), bankWindow.Window)
-		}
+	report, migrateErr := savedata.MigrateSave(gameSave)
+	if migrateErr != nil {
+		dialog.ShowError(unwritableError(migrateErr), bankWindow.Window)
+	} else if report.Migrated() {
+		dialog.ShowInformation("Save Updated", report.String()+"\n\nThe update is written with your next change.", bankWindow.Window)
+	}
 
+	session, err := newSaveSession(filePath, mode, gameSave, bank, bankWindow.Backups, bankWindow.Journal, bankWindow.Activity, bankWindow.Window)
+	if err != nil {
+		dialog.ShowError(err, bankWindow.Window)
+		return
+	}
+	session.Locked = bankWindow.gameRunning()
+	// A bank that can't be written was already reported when it was loaded.
+	session.Unwritable = errors.Join(migrateErr, savedata.CheckWritable(bank.SaveVersion))
+	bankWindow.Session = session
+	watchOpenSave(bankWindow)
+
+	onChange := session.Commit
+	// Box names live beside the save, not in it, so naming a box only redraws.
+	boxes := bankWindow.Boxes.forSave(filePath)
+	onEditBox := func(box int) {
+		editBox(bankWindow.Boxes, boxes, box, bankWindow.Window, session.OnChanged)
+	}
+	session.OnChanged = func() {
 		if bankWindow.TeamTab != nil {
-			bankWindow.TeamTab.Content = createTeamTab(gameSave, bank, onSave, onBankUpdate, bankWindow.Window)
+			bankWindow.TeamTab.Content = createTeamTab(gameSave, bank, boxes, onChange, session.ReadOnly(), bankWindow.Window)
 		}
 
 		if bankWindow.StorageTab != nil {
-			bankWindow.StorageTab.Content = createStorageTab(gameSave, bank, onSave, onBankUpdate, bankWindow.Window)
+			// Stay on the box that was showing, e.g. after a drag and drop in it.
+			selectedBox := 0
+			if boxTabs, ok := bankWindow.StorageTab.Content.(*container.AppTabs); ok {
+				selectedBox = boxTabs.SelectedIndex()
+			}
+			bankWindow.StorageTab.Content = createStorageTab(gameSave, bank, boxes, onEditBox, onChange, session.ReadOnly(), bankWindow.Window)
+			if boxTabs, ok := bankWindow.StorageTab.Content.(*container.AppTabs); ok && selectedBox > 0 && selectedBox < len(boxTabs.Items) {
+				boxTabs.SelectIndex(selectedBox)
+			}
 		}
 
 		if bankWindow.BankTab != nil {
-			bankWindow.BankTab.Content = createBankTab(gameSave, bank, onSave, onBankUpdate, bankWindow.Window)
+			bankWindow.BankTab.Content = createBankTab(gameSave, bank, boxes, bankWindow.Settings, onChange, session.ReadOnly(), bankWindow.Window)
 		}
 
 		bankWindow.Tabs.Refresh()
+		if bankWindow.HistoryList != nil {
+			bankWindow.HistoryList.Refresh()
+		}
+		refreshPendingPanel(bankWindow)
+		refreshValidation(bankWindow)
+		if bankWindow.refreshActivity != nil {
+			bankWindow.refreshActivity()
+		}
 	}
 
-	bankWindow.Tabs.Append(container.NewTabItem("Team", createTeamTab(gameSave, bank, onSave, onBankUpdate, bankWindow.Window)))
-  bankWindow.Tabs.Append(container.NewTabItem("Storage", createStorageTab(gameSave, bank, onSave, onBankUpdate, bankWindow.Window)))
-  bankWindow.Tabs.Append(container.NewTabItem("Bank", createBankTab(gameSave, bank, onSave, onBankUpdate, bankWindow.Window)))
+	// Reopening a save (e.g. after a restore) replaces the tabs of the previous one.
+	bankWindow.TeamTab = container.NewTabItem("Team", createTeamTab(gameSave, bank, boxes, onChange, session.ReadOnly(), bankWindow.Window))
+	bankWindow.StorageTab = container.NewTabItem("Storage", createStorageTab(gameSave, bank, boxes, onEditBox, onChange, session.ReadOnly(), bankWindow.Window))
+	bankWindow.BankTab = container.NewTabItem("Bank", createBankTab(gameSave, bank, boxes, bankWindow.Settings, onChange, session.ReadOnly(), bankWindow.Window))
+	bankWindow.Tabs.SetItems([]*container.TabItem{bankWindow.TeamTab, bankWindow.StorageTab, bankWindow.BankTab})
+	if bankWindow.HistoryList != nil {
+		bankWindow.HistoryList.Refresh()
+	}
+	refreshPendingPanel(bankWindow)
+	refreshValidation(bankWindow)
 
 	bankWindow.Window.SetContent(bankWindow.MainContent)
+	checkSaveOnLoad(bankWindow)
 }
 
-func showFilePickerDialog(bankWindow *BankWindow, bank *Bank) {
+// closeSave forgets the open save's edit history and goes back to the welcome screen.
+func closeSave(bankWindow *BankWindow) {
+	if bankWindow.Session == nil {
+		return
+	}
+	confirmDiscardPending(bankWindow, func() {
+		forgetSave(bankWindow)
+	})
+}
+
+func forgetSave(bankWindow *BankWindow) {
+	if err := deleteEditHistory(); err != nil {
+		dialog.ShowError(fmt.Errorf("error removing edit history: %w", err), bankWindow.Window)
+	}
+	bankWindow.Session = nil
+	watchOpenSave(bankWindow)
+	if bankWindow.HistoryList != nil {
+		bankWindow.HistoryList.Refresh()
+	}
+	refreshValidation(bankWindow)
+	bankWindow.Window.SetContent(bankWindow.WelcomeContent)
+}
+
+func showFilePickerDialog(bankWindow *BankWindow, bank *savedata.Bank) {
 	dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
 		if err != nil {
 			dialog.ShowError(err, bankWindow.Window)
@@ -813,7 +891,36 @@ func showFilePickerDialog(bankWindow *BankWindow, bank *Bank) {
 	}, bankWindow.Window)
 }
 
+// runCommand runs a command-line command instead of opening the app, on the
+// save, bank and backups the app would use.
+func runCommand(args []string) int {
+	settings, err := loadSettings()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error loading settings: %v\n", err)
+		settings = &Settings{}
+	}
+
+	config := cli.Config{SavePath: getDefaultSavePath(settings)}
+	if bankPath, err := getBankFilePath(); err == nil {
+		config.BankPath = bankPath
+	}
+	if backupDir, err := getBackupDirPath(); err == nil {
+		config.Backups = backup.NewManager(backupDir, backupPolicy(settings))
+	}
+	if journalPath, err := getJournalFilePath(); err == nil {
+		config.Journal = journal.New(journalPath)
+	}
+	if activityPath, err := getActivityLogPath(); err == nil {
+		config.Activity = activity.New(activityPath, "command line")
+	}
+	return cli.Run(args, config)
+}
+
 func main() {
+	if len(os.Args) > 1 && cli.IsCommand(os.Args[1]) {
+		os.Exit(runCommand(os.Args[1:]))
+	}
+
 	myApp := app.NewWithID("com.primaryartemis.pandorasbank")
 	bankWindow := BankWindow {
 		Window: myApp.NewWindow("Pandora's Bank"),
@@ -826,11 +933,36 @@ func main() {
 		dialog.ShowError(fmt.Errorf("error loading settings: %w", err), myWindow)
 		settings = &Settings{}
 	}
+	bankWindow.Settings = settings
+
+	boxes, err := loadBoxStore()
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("error loading box names: %w", err), myWindow)
+	}
+	bankWindow.Boxes = boxes
+
+	journalPath, err := getJournalFilePath()
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("error locating transfer journal: %w", err), myWindow)
+		journalPath = "pbank_journal.json"
+	}
+	bankWindow.Journal = journal.New(journalPath)
+	recoverTransfer(bankWindow.Journal, myWindow)
+
+	if activityPath, err := getActivityLogPath(); err != nil {
+		dialog.ShowError(fmt.Errorf("error locating activity log, changes won't be logged: %w", err), myWindow)
+	} else {
+		bankWindow.Activity = activity.New(activityPath, "app")
+	}
 
 	bank, err := loadBank()
 	if err != nil {
 		dialog.ShowError(fmt.Errorf("error loading bank: %w", err), myWindow)
-		bank = &Bank{Elestrals: []*Elestral{}}
+		bank = savedata.NewBank()
+	} else if report, err := savedata.MigrateBank(bank); err != nil {
+		dialog.ShowError(fmt.Errorf("%w\n\nThe bank can be viewed but changes to it will not be written.", err), myWindow)
+	} else if len(report.Changes) > 0 {
+		dialog.ShowInformation("Bank Updated", report.String(), myWindow)
 	}
 
 	defaultSavePath := getDefaultSavePath(settings)
@@ -839,12 +971,235 @@ func main() {
 
 	gamePath := steam.GetDefaultGamePath(settings.CustomGamePath)
 
-	_, bankWindow.Footer = quickstart.NewQuickStartUI(bankWindow.Window, gamePath, func(newGamePath string) {
+	backupDir, err := getBackupDirPath()
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("error locating backup directory: %w", err), myWindow)
+		backupDir = "pbank_backups"
+	}
+	bankWindow.Backups = backup.NewManager(backupDir, backupPolicy(settings))
+
+	watcher, err := filewatch.New(fileSettleDelay, func() {
+		fyne.Do(func() {
+			checkExternalChanges(&bankWindow)
+		})
+	}, func(err error) {
+		fyne.Do(func() {
+			dialog.ShowError(fmt.Errorf("error watching the save for changes: %w", err), myWindow)
+		})
+	})
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("error watching the save for changes, it won't reload when the game saves: %w", err), myWindow)
+	} else {
+		bankWindow.Watcher = watcher
+		defer watcher.Close()
+	}
+
+	activeSavePath := func() string {
+		if bankWindow.Session != nil {
+			return bankWindow.Session.Path
+		}
+		return defaultSavePath
+	}
+
+	quickStartUI, footer := quickstart.NewQuickStartUI(bankWindow.Window, gamePath, func(newGamePath string) {
 		settings.CustomGamePath = newGamePath
 	  saveSettings(settings)
 	})
+	bankWindow.Footer = footer
+
+	quickStartUI.OnGameStart(func() {
+		if path := activeSavePath(); path != "" {
+			if _, err := bankWindow.Backups.Snapshot(path, backup.ReasonLaunch); err != nil {
+				dialog.ShowError(fmt.Errorf("error backing up save before launch: %w", err), myWindow)
+			}
+		}
+
+		bankWindow.gameLaunched = true
+		updateEditLock(&bankWindow)
+		updateBackupTimer(&bankWindow, activeSavePath)
+	})
+	quickStartUI.OnGameExit(func() {
+		fyne.Do(func() {
+			bankWindow.gameLaunched = false
+			updateEditLock(&bankWindow)
+			updateBackupTimer(&bankWindow, activeSavePath)
+			// The game saves on exit; don't wait for the watcher to notice.
+			checkExternalChanges(&bankWindow)
+		})
+	})
+
+	bankWindow.EditMode = defaultEditMode(settings)
+	bankWindow.PendingPanel = container.NewVBox()
+	bankWindow.LockBanner = container.NewVBox()
+	bankWindow.MainContent = container.NewBorder(container.NewVBox(bankWindow.LockBanner, bankWindow.PendingPanel), bankWindow.Footer, nil, nil, bankWindow.Tabs)
+
+	pollCtx, stopPolling := context.WithCancel(context.Background())
+	defer stopPolling()
+	gameproc.Poll(pollCtx, gamePollInterval, func(running bool) {
+		fyne.Do(func() {
+			bankWindow.gameProcessRunning = running
+			updateEditLock(&bankWindow)
+			updateBackupTimer(&bankWindow, activeSavePath)
+		})
+	})
 
-	bankWindow.MainContent = container.NewBorder(nil, bankWindow.Footer, nil, nil, bankWindow.Tabs)
+	// A restore over the open save reloads it so the next edit doesn't write the old data back.
+	onRestored := func() {
+		if bankWindow.Session != nil {
+			displayGameSave(bankWindow.Session.Path, bank, &bankWindow)
+		}
+	}
+
+	onImported := func(parts []archive.Part) {
+		for _, part := range parts {
+			switch part {
+			case archive.PartBank:
+				importedBank, err := loadBank()
+				if err != nil {
+					dialog.ShowError(fmt.Errorf("error loading bank: %w", err), myWindow)
+					continue
+				}
+				if _, err := savedata.MigrateBank(importedBank); err != nil {
+					dialog.ShowError(fmt.Errorf("%w\n\nThe bank can be viewed but changes to it will not be written.", err), myWindow)
+				}
+				*bank = *importedBank
+			case archive.PartSettings:
+				importedSettings, err := loadSettings()
+				if err != nil {
+					dialog.ShowError(fmt.Errorf("error loading settings: %w", err), myWindow)
+					continue
+				}
+				*settings = *importedSettings
+				bankWindow.Backups.SetPolicy(backupPolicy(settings))
+				bankWindow.EditMode = defaultEditMode(settings)
+			}
+		}
+		onRestored()
+	}
+
+	onBrowseElestrals := func(path string) {
+		showBackupElestrals(path, &bankWindow)
+	}
+
+	undo := func() {
+		if bankWindow.Session != nil {
+			bankWindow.Session.Undo()
+		}
+	}
+	redo := func() {
+		if bankWindow.Session != nil {
+			bankWindow.Session.Redo()
+		}
+	}
+	myWindow.Canvas().AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyZ, Modifier: fyne.KeyModifierShortcutDefault}, func(fyne.Shortcut) {
+		undo()
+	})
+	myWindow.Canvas().AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyY, Modifier: fyne.KeyModifierShortcutDefault}, func(fyne.Shortcut) {
+		redo()
+	})
+
+	stagedEditingItem := fyne.NewMenuItem("Staged Editing", nil)
+	stagedEditingItem.Checked = settings.StagedEditing
+	stagedEditingItem.Action = func() {
+		setStagedEditing(&bankWindow, settings, !settings.StagedEditing)
+		stagedEditingItem.Checked = settings.StagedEditing
+		myWindow.MainMenu().Refresh()
+	}
+
+	myWindow.SetMainMenu(fyne.NewMainMenu(
+		fyne.NewMenu("File",
+			fyne.NewMenuItem("Open Sandbox Copy", func() {
+				path := activeSavePath()
+				if path == "" {
+					dialog.ShowInformation("Sandbox", "Set a default save location or open a save first.", myWindow)
+					return
+				}
+				confirmDiscardPending(&bankWindow, func() {
+					openGameSave(path, bank, &bankWindow, editSandbox)
+				})
+			}),
+			fyne.NewMenuItem("Close Save", func() {
+				closeSave(&bankWindow)
+			}),
+			fyne.NewMenuItemSeparator(),
+			fyne.NewMenuItem("Backups...", func() {
+				if path := activeSavePath(); path != "" {
+					showBackupBrowser(&bankWindow, path, onRestored, onBrowseElestrals)
+				} else {
+					dialog.ShowInformation("Backups", "Open a save first to choose which save backups are restored over.", myWindow)
+				}
+			}),
+			fyne.NewMenuItem("Restore Save...", func() {
+				if path := activeSavePath(); path != "" {
+					restoreSaveFile(path, &bankWindow, onRestored)
+				} else {
+					dialog.ShowInformation("Restore Save", "Open a save first to choose which save is restored.", myWindow)
+				}
+			}),
+			fyne.NewMenuItem("Repair Save...", func() {
+				pickSaveToRepair(&bankWindow, bank)
+			}),
+			fyne.NewMenuItem("Recover Elestrals from Backup...", func() {
+				pickBackupElestrals(&bankWindow)
+			}),
+			fyne.NewMenuItemSeparator(),
+			fyne.NewMenuItem("Compare With Current Save...", func() {
+				path := activeSavePath()
+				if path == "" {
+					dialog.ShowInformation("Compare Saves", "Open a save first to compare other saves with it.", myWindow)
+					return
+				}
+				pickSaveFile(myWindow, func(otherPath string) {
+					compareSaveFiles(otherPath, path, myWindow)
+				})
+			}),
+			fyne.NewMenuItem("Compare Two Saves...", func() {
+				pickSaveFile(myWindow, func(beforePath string) {
+					pickSaveFile(myWindow, func(afterPath string) {
+						compareSaveFiles(beforePath, afterPath, myWindow)
+					})
+				})
+			}),
+			fyne.NewMenuItemSeparator(),
+			fyne.NewMenuItem("Export Everything...", func() {
+				exportEverything(activeSavePath(), myWindow)
+			}),
+			fyne.NewMenuItem("Import Archive...", func() {
+				savePath := activeSavePath()
+				if savePath == "" {
+					savePath = getStandardSavePath()
+				}
+				importArchive(savePath, &bankWindow, onImported)
+			}),
+			fyne.NewMenuItemSeparator(),
+			fyne.NewMenuItem("Backup Settings...", func() {
+				showBackupSettings(settings, bankWindow.Backups, myWindow)
+			}),
+		),
+		fyne.NewMenu("Edit",
+			fyne.NewMenuItem("Undo", undo),
+			fyne.NewMenuItem("Redo", redo),
+			fyne.NewMenuItemSeparator(),
+			fyne.NewMenuItem("History...", func() {
+				showEditHistory(&bankWindow)
+			}),
+			fyne.NewMenuItem("Organise Storage...", func() {
+				showOrganise(&bankWindow)
+			}),
+			fyne.NewMenuItem("Check Save...", func() {
+				showValidation(&bankWindow)
+			}),
+			fyne.NewMenuItem("Activity Log...", func() {
+				showActivityLog(&bankWindow)
+			}),
+			fyne.NewMenuItemSeparator(),
+			stagedEditingItem,
+		),
+	))
+
+	myWindow.SetCloseIntercept(func() {
+		confirmDiscardPending(&bankWindow, myWindow.Close)
+	})
 
 	if defaultSavePath != "" {
 		welcomeLabel := widget.NewLabel("Welcome to Pandora's Bank!\n\nA game save file was found at the default location.")
@@ -863,13 +1218,21 @@ func main() {
 		})
 
 		restoreButton := widget.NewButton("Restore Save", func() {
-			restoreSaveFile(defaultSavePath, myWindow)
+			restoreSaveFile(defaultSavePath, &bankWindow, onRestored)
+		})
+
+		browseBackupsButton := widget.NewButton("Browse Backups", func() {
+			showBackupBrowser(&bankWindow, defaultSavePath, onRestored, nil)
 		})
 
 		changeDefaultButton := widget.NewButton("Change Default Save Location", func() {
 			changeDefaultSaveLocation(settings, myWindow)
 		})
 
+		backupSettingsButton := widget.NewButton("Backup Settings", func() {
+			showBackupSettings(settings, bankWindow.Backups, myWindow)
+		})
+
 		bankWindow.WelcomeContent = container.NewVBox(
 			widget.NewLabel(""),
 			welcomeLabel,
@@ -879,9 +1242,9 @@ func main() {
 			widget.NewLabel(""),
 			widget.NewSeparator(),
 			widget.NewLabel(""),
-			container.NewCenter(container.NewHBox(backupButton, restoreButton)),
+			container.NewCenter(container.NewHBox(backupButton, restoreButton, browseBackupsButton)),
 			widget.NewLabel(""),
-			container.NewCenter(changeDefaultButton),
+			container.NewCenter(container.NewHBox(changeDefaultButton, backupSettingsButton)),
 		)
 	} else {
 		welcomeLabel := widget.NewLabel("Welcome to Pandora's Bank!\n\nPlease select a game save file to view your Elestrals.")
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"pandorasbank/savedata"
+)
+
+// showOrganise opens a window that sorts the open save's storage across all
+// boxes, with a preview of the new layout that is only written on Organise.
+func showOrganise(bankWindow *BankWindow) {
+	session := bankWindow.Session
+	if session == nil {
+		dialog.ShowInformation("Organise Storage", "Open a save first to organise its storage.", bankWindow.Window)
+		return
+	}
+	gameSave := session.GameSave
+	boxes := bankWindow.Boxes.forSave(session.Path)
+
+	organiseWindow := fyne.CurrentApp().NewWindow("Organise Storage")
+
+	const noTieBreaker = "Nothing (keep current order)"
+	var labels []string
+	for _, key := range savedata.SortKeys {
+		labels = append(labels, key.Label())
+	}
+	bySelect := widget.NewSelect(labels, nil)
+	thenSelect := widget.NewSelect(append([]string{noTieBreaker}, labels...), nil)
+	emptyAtEndCheck := widget.NewCheck("Empty entries at the end", nil)
+
+	options := func() savedata.OrganiseOptions {
+		options := savedata.OrganiseOptions{By: savedata.SortKeys[max(bySelect.SelectedIndex(), 0)], EmptyAtEnd: emptyAtEndCheck.Checked}
+		if i := thenSelect.SelectedIndex(); i > 0 {
+			options.ThenBy = savedata.SortKeys[i-1]
+		}
+		return options
+	}
+
+	summaryLabel := widget.NewLabel("")
+	previewLabel := widget.NewLabel("")
+	var organiseButton *widget.Button
+
+	refreshPreview := func() {
+		layout, err := savedata.PlanOrganise(gameSave, options())
+		if err != nil {
+			summaryLabel.SetText(err.Error())
+			organiseButton.Disable()
+			return
+		}
+
+		now := map[*savedata.Elestral]savedata.Location{}
+		savedata.Each(gameSave, nil, func(l savedata.Location, e *savedata.Elestral) {
+			now[e] = l
+		}, savedata.InStorage)
+
+		var lines []string
+		for boxIdx, box := range layout {
+			lines = append(lines, boxes.Title(boxIdx))
+			for entryIdx, e := range box {
+				at := savedata.AtStorage(savedata.StorageSlot{Box: boxIdx, Entry: entryIdx})
+				if e == nil {
+					lines = append(lines, fmt.Sprintf("    %d. (empty)", entryIdx+1))
+					continue
+				}
+				line := fmt.Sprintf("    %d. %s (%s, Lvl %d)", entryIdx+1, e.Name, e.Species, e.CurrentLevel)
+				if from := now[e]; from != at {
+					line += "  <- " + boxes.Slot(from.Storage())
+				}
+				lines = append(lines, line)
+			}
+		}
+		previewLabel.SetText(strings.Join(lines, "\n"))
+
+		moved := layout.Moved(gameSave)
+		summaryLabel.SetText(fmt.Sprintf("%d Elestrals will move.", moved))
+		if moved == 0 || session.ReadOnly() {
+			organiseButton.Disable()
+		} else {
+			organiseButton.Enable()
+		}
+	}
+
+	organiseButton = widget.NewButton("Organise", func() {
+		chosen := options()
+		moved, err := savedata.Organise(gameSave, chosen)
+		if err != nil {
+			dialog.ShowError(err, organiseWindow)
+			return
+		}
+		description := chosen.By.Label()
+		if chosen.ThenBy != "" {
+			description += ", then " + chosen.ThenBy.Label()
+		}
+		session.Commit(fmt.Sprintf("Organise storage by %s", description))
+		dialog.ShowInformation("Storage Organised", fmt.Sprintf("%d Elestrals were moved.", moved), bankWindow.Window)
+		organiseWindow.Close()
+	})
+	cancelButton := widget.NewButton("Cancel", organiseWindow.Close)
+
+	bySelect.OnChanged = func(string) { refreshPreview() }
+	thenSelect.OnChanged = func(string) { refreshPreview() }
+	emptyAtEndCheck.OnChanged = func(bool) { refreshPreview() }
+	bySelect.SetSelectedIndex(0)
+	thenSelect.SetSelectedIndex(0)
+
+	form := widget.NewForm(
+		widget.NewFormItem("Sort by", bySelect),
+		widget.NewFormItem("Then by", thenSelect),
+		widget.NewFormItem("", emptyAtEndCheck),
+	)
+	footer := container.NewBorder(nil, nil, summaryLabel, container.NewHBox(cancelButton, organiseButton))
+	organiseWindow.SetContent(container.NewBorder(form, footer, nil, nil, container.NewVScroll(previewLabel)))
+	organiseWindow.Resize(fyne.NewSize(600, 600))
+	organiseWindow.Show()
+}
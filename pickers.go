@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"pandorasbank/savedata"
+)
+
+// pickStorageBox asks which storage box to put an Elestral in, showing how
+// many free entries each one has. Full boxes can't be picked.
+func pickStorageBox(gameSave *savedata.GameSave, boxes saveBoxes, title string, myWindow fyne.Window, onPicked func(box int)) {
+	var pickDialog dialog.Dialog
+	boxButtons := container.NewVBox()
+	for boxIdx := range gameSave.StorageBoxes {
+		box := boxIdx
+		free := savedata.FreeSlots(gameSave, box)
+		button := widget.NewButtonWithIcon(fmt.Sprintf("%s (%d free)", boxes.Title(box), free), boxes.Icon(box), func() {
+			pickDialog.Hide()
+			onPicked(box)
+		})
+		if free == 0 {
+			button.Disable()
+		}
+		boxButtons.Add(button)
+	}
+	if len(gameSave.StorageBoxes) == 0 {
+		boxButtons.Add(widget.NewLabel("This save has no storage boxes."))
+	}
+
+	pickDialog = dialog.NewCustom(title, "Cancel", container.NewVScroll(boxButtons), myWindow)
+	pickDialog.Resize(fyne.NewSize(320, 400))
+	pickDialog.Show()
+}
+
+// swapIntoParty brings the Elestral at from into a full party: the party
+// member picked goes back to the storage entry it leaves. Both moves are one
+// change, so they are written together.
+func swapIntoParty(gameSave *savedata.GameSave, bank *savedata.Bank, boxes saveBoxes, from savedata.Location, onChange func(action string), myWindow fyne.Window) {
+	incoming, err := savedata.Get(gameSave, bank, from)
+	if err != nil || incoming == nil {
+		dialog.ShowError(fmt.Errorf("error reading %s: %w", from, err), myWindow)
+		return
+	}
+	incomingName := incoming.Name
+	fromName := boxes.Slot(from.Storage())
+
+	var pickDialog dialog.Dialog
+	members := container.NewVBox(widget.NewLabel(fmt.Sprintf("Which party member should go to %s in place of %s?", fromName, incomingName)))
+	for i, e := range gameSave.ActivePlayerData.Party() {
+		if e.Empty() {
+			continue
+		}
+		partySlot := savedata.AtParty(i)
+		outgoingName := e.Name
+		button := widget.NewButton(fmt.Sprintf("%s: %s (%s, Lv %d)", partySlot, e.Name, e.Species, e.CurrentLevel), func() {
+			pickDialog.Hide()
+			summary := fmt.Sprintf("%s will move from %s to %s.\n%s will move from %s to %s.\n\nSwap them?",
+				incomingName, fromName, partySlot, outgoingName, partySlot, fromName)
+			dialog.ShowConfirm("Swap into Party", summary, func(confirm bool) {
+				if !confirm {
+					return
+				}
+				if err := savedata.Swap(gameSave, bank, from, partySlot); err != nil {
+					dialog.ShowError(err, myWindow)
+					return
+				}
+				if onChange != nil {
+					onChange(fmt.Sprintf("Swap %s into the party for %s", incomingName, outgoingName))
+				}
+				dialog.ShowInformation("Swap Successful",
+					fmt.Sprintf("%s is now in %s and %s is in %s.", incomingName, partySlot, outgoingName, fromName), myWindow)
+			}, myWindow)
+		})
+		members.Add(button)
+	}
+
+	pickDialog = dialog.NewCustom("Party Is Full", "Cancel", members, myWindow)
+	pickDialog.Show()
+}
+
+// importDestination is where a bank import goes: the party, or entry Entry of
+// storage box Box, or the box's first free entry when Entry is -1.
+type importDestination struct {
+	Party bool
+	Box   int
+	Entry int
+}
+
+// pickImportDestination asks where to import the Elestral called name, with
+// a meter of how full each storage box is. The box picked is remembered in
+// settings and offered first next time.
+func pickImportDestination(gameSave *savedata.GameSave, boxes saveBoxes, settings *Settings, name string, myWindow fyne.Window, onPicked func(to importDestination)) {
+	const firstFree = "First free entry"
+	boxCount := len(gameSave.StorageBoxes)
+
+	var destinations []string
+	meters := container.NewVBox()
+	for box, storageBox := range gameSave.StorageBoxes {
+		total := len(storageBox.Entries)
+		free := savedata.FreeSlots(gameSave, box)
+		label := fmt.Sprintf("%s (%d free)", boxes.Title(box), free)
+		if free == 0 {
+			label = fmt.Sprintf("%s (full)", boxes.Title(box))
+		}
+		destinations = append(destinations, label)
+
+		meter := widget.NewProgressBar()
+		meter.Max = float64(max(total, 1))
+		meter.SetValue(float64(total - free))
+		meter.TextFormatter = func() string {
+			return fmt.Sprintf("%d/%d", total-free, total)
+		}
+		meterLabel := container.NewHBox(widget.NewLabel(boxes.Title(box)))
+		if icon := boxes.Icon(box); icon != nil {
+			meterLabel.Objects = append([]fyne.CanvasObject{widget.NewIcon(icon)}, meterLabel.Objects...)
+		}
+		meters.Add(container.NewBorder(nil, nil, meterLabel, nil, meter))
+	}
+	partySize := savedata.PartySize(gameSave)
+	partyLabel := "Party"
+	if savedata.PartyFull(gameSave) {
+		partyLabel = "Party (full)"
+	}
+	destinations = append(destinations, partyLabel)
+
+	partyMeter := widget.NewProgressBar()
+	partyMeter.Max = float64(len(gameSave.ActivePlayerData.Party()))
+	partyMeter.SetValue(float64(partySize))
+	partyMeter.TextFormatter = func() string {
+		return fmt.Sprintf("%d/%d", partySize, len(gameSave.ActivePlayerData.Party()))
+	}
+	meters.Add(container.NewBorder(nil, nil, widget.NewLabel("Party"), nil, partyMeter))
+
+	// freeEntries are the empty entries of the chosen box, in the order the
+	// entry picker lists them after firstFree.
+	var freeEntries []int
+	entrySelect := widget.NewSelect(nil, nil)
+	destinationSelect := widget.NewSelect(destinations, nil)
+	destinationSelect.OnChanged = func(string) {
+		box := destinationSelect.SelectedIndex()
+		freeEntries = nil
+		if box < 0 || box >= boxCount {
+			entrySelect.SetOptions(nil)
+			entrySelect.ClearSelected()
+			entrySelect.Disable()
+			return
+		}
+		options := []string{firstFree}
+		for entryIdx, entry := range gameSave.StorageBoxes[box].Entries {
+			if entry.CharacterData.Empty() {
+				options = append(options, fmt.Sprintf("Entry %d", entryIdx+1))
+				freeEntries = append(freeEntries, entryIdx)
+			}
+		}
+		entrySelect.Enable()
+		entrySelect.SetOptions(options)
+		entrySelect.SetSelectedIndex(0)
+	}
+	if settings != nil && settings.LastImportBox < boxCount {
+		destinationSelect.SetSelectedIndex(settings.LastImportBox)
+	} else {
+		destinationSelect.SetSelectedIndex(0)
+	}
+
+	form := widget.NewForm(
+		widget.NewFormItem("Destination", destinationSelect),
+		widget.NewFormItem("Entry", entrySelect),
+	)
+	content := container.NewVBox(form, widget.NewSeparator(), meters)
+
+	dialog.ShowCustomConfirm(fmt.Sprintf("Import %s", name), "Import", "Cancel", content, func(confirm bool) {
+		if !confirm {
+			return
+		}
+		box := destinationSelect.SelectedIndex()
+		if box < 0 {
+			return
+		}
+		if box == boxCount {
+			onPicked(importDestination{Party: true})
+			return
+		}
+
+		to := importDestination{Box: box, Entry: -1}
+		if i := entrySelect.SelectedIndex(); i > 0 {
+			to.Entry = freeEntries[i-1]
+		}
+		if settings != nil && settings.LastImportBox != box {
+			settings.LastImportBox = box
+			if err := saveSettings(settings); err != nil {
+				dialog.ShowError(fmt.Errorf("error saving settings: %w", err), myWindow)
+			}
+		}
+		onPicked(to)
+	}, myWindow)
+}
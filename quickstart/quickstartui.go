@@ -9,13 +9,18 @@ import (
 )
 
 type QuickStartUI interface {
-
+	// OnGameStart registers f to run just before the game is launched.
+	OnGameStart(f func())
+	// OnGameExit registers f to run after a launched game exits, or fails to launch. It is called from a goroutine.
+	OnGameExit(f func())
 }
 
 type quickStartUI struct {
 	quickStarter QuickStarter
 	knownPath string
 	updateCustomApplicationPath func(string)
+	onGameStart []func()
+	onGameExit []func()
 
 	window fyne.Window
 
@@ -58,11 +63,19 @@ func startGameButton(ui *quickStartUI) {
 		return
 	}
 
+	for _, f := range ui.onGameStart {
+		f()
+	}
+
 	err := ui.quickStarter.Start(ui.knownPath, func() {
 		stopGameButton(ui)
 	})
 
 	if err != nil {
+		for _, f := range ui.onGameExit {
+			f()
+		}
+
 		// Failure to run?  Bad path maybe
 		ui.knownPath = ""
 		confirmFilePicker(ui)
@@ -113,6 +126,18 @@ func stopGameButton(ui *quickStartUI) {
 
 	// Possible call by goroutine
 	fyne.Do(func() { ui.container.Refresh() })
+
+	for _, f := range ui.onGameExit {
+		f()
+	}
+}
+
+func (ui *quickStartUI) OnGameStart(f func()) {
+	ui.onGameStart = append(ui.onGameStart, f)
+}
+
+func (ui *quickStartUI) OnGameExit(f func()) {
+	ui.onGameExit = append(ui.onGameExit, f)
 }
 
 
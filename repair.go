@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+
+	"pandorasbank/gameproc"
+	"pandorasbank/savedata"
+)
+
+// offerRepair replaces the plain error for a save that exists but can't be
+// read with the option to repair a copy of it.
+func offerRepair(bankWindow *BankWindow, bank *savedata.Bank, path string, loadErr error) {
+	messageLabel := widget.NewLabel(fmt.Sprintf("%v\n\nPandora's Bank can try to recover what it can into a new file. The original is left as it is.", loadErr))
+	messageLabel.Wrapping = fyne.TextWrapWord
+
+	repairDialog := dialog.NewCustomConfirm("Can't Open Save", "Try Repair...", "Close", messageLabel, func(repair bool) {
+		if repair {
+			showRepairSave(bankWindow, bank, path)
+		}
+	}, bankWindow.Window)
+	repairDialog.Resize(fyne.NewSize(500, 250))
+	repairDialog.Show()
+}
+
+func pickSaveToRepair(bankWindow *BankWindow, bank *savedata.Bank) {
+	dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, bankWindow.Window)
+			return
+		}
+		if reader == nil {
+			return
+		}
+		path := reader.URI().Path()
+		reader.Close()
+		showRepairSave(bankWindow, bank, path)
+	}, bankWindow.Window)
+}
+
+// showRepairSave repairs the save at path in memory and shows what was
+// salvaged, with buttons to write it to a new file.
+func showRepairSave(bankWindow *BankWindow, bank *savedata.Bank, path string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("error reading save: %w", err), bankWindow.Window)
+		return
+	}
+	gameSave, report, err := savedata.RepairSave(data)
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("the save could not be repaired: %w", err), bankWindow.Window)
+		return
+	}
+
+	repairWindow := fyne.CurrentApp().NewWindow("Repair Save")
+
+	reportLabel := widget.NewLabel(report.String())
+	reportLabel.Wrapping = fyne.TextWrapWord
+
+	// The original is never overwritten; the repaired copy always gets a name
+	// that isn't taken yet.
+	writeTo := func(destPath string) {
+		if err := gameproc.CheckClosed(); err != nil {
+			dialog.ShowError(err, repairWindow)
+			return
+		}
+		if err := savedata.WriteSave(destPath, gameSave, savedata.WriteOptions{}); err != nil {
+			dialog.ShowError(fmt.Errorf("error writing repaired save: %w", err), repairWindow)
+			return
+		}
+		dialog.ShowConfirm("Repaired Save Written",
+			fmt.Sprintf("The repaired save was written to:\n%s\n\nThe game only loads gamesave.json, so once you've checked the copy, replace the original with it.\n\nOpen it now?", destPath),
+			func(open bool) {
+				if open {
+					repairWindow.Close()
+					displayGameSave(destPath, bank, bankWindow)
+				}
+			}, repairWindow)
+	}
+
+	saveNextToButton := widget.NewButton("Save Next to Original", func() {
+		writeTo(savedata.RepairedPath(path))
+	})
+	saveNextToButton.Importance = widget.HighImportance
+	saveElsewhereButton := widget.NewButton("Save in Folder...", func() {
+		folderDialog := dialog.NewFolderOpen(func(folder fyne.ListableURI, err error) {
+			if err != nil {
+				dialog.ShowError(err, repairWindow)
+				return
+			}
+			if folder == nil {
+				return
+			}
+			writeTo(savedata.RepairedPath(filepath.Join(folder.Path(), filepath.Base(path))))
+		}, repairWindow)
+		if dirURI, err := storage.ListerForURI(storage.NewFileURI(filepath.Dir(path))); err == nil {
+			folderDialog.SetLocation(dirURI)
+		}
+		folderDialog.Show()
+	})
+
+	header := widget.NewLabel(fmt.Sprintf("Recovered from %s:", path))
+	header.Wrapping = fyne.TextWrapWord
+	buttons := container.NewHBox(saveNextToButton, saveElsewhereButton)
+
+	repairWindow.SetContent(container.NewBorder(header, buttons, nil, nil, container.NewVScroll(reportLabel)))
+	repairWindow.Resize(fyne.NewSize(600, 500))
+	repairWindow.Show()
+}
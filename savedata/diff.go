@@ -0,0 +1,341 @@
+package savedata
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+type ChangeKind string
+
+const (
+	ChangeAdded   ChangeKind = "added"
+	ChangeRemoved ChangeKind = "removed"
+	ChangeChanged ChangeKind = "changed"
+	ChangeMoved   ChangeKind = "moved"
+)
+
+type ChangeCategory string
+
+const (
+	CategorySave     ChangeCategory = "save"
+	CategoryPlayer   ChangeCategory = "player"
+	CategoryElestral ChangeCategory = "elestral"
+	CategoryFlag     ChangeCategory = "flag"
+	CategoryBoon     ChangeCategory = "boon"
+	CategoryBank     ChangeCategory = "bank"
+)
+
+var changeCategoryTitles = []struct {
+	category ChangeCategory
+	title    string
+}{
+	{CategorySave, "Save"},
+	{CategoryPlayer, "Player"},
+	{CategoryElestral, "Elestrals"},
+	{CategoryFlag, "Game Flags"},
+	{CategoryBoon, "Boons"},
+	{CategoryBank, "Bank"},
+}
+
+// SaveChange is one semantic difference between two saves. Subject names what
+// changed (a player field, an Elestral, a flag or boon) and Field, for
+// Elestrals, which of its values.
+type SaveChange struct {
+	Category ChangeCategory `json:"category"`
+	Kind     ChangeKind     `json:"kind"`
+	Subject  string         `json:"subject"`
+	Hash     string         `json:"hash,omitempty"`
+	Field    string         `json:"field,omitempty"`
+	Before   any            `json:"before,omitempty"`
+	After    any            `json:"after,omitempty"`
+}
+
+func (change SaveChange) String() string {
+	switch change.Kind {
+	case ChangeAdded:
+		if change.After != nil {
+			return fmt.Sprintf("+ %s (%v)", change.Subject, change.After)
+		}
+		return "+ " + change.Subject
+	case ChangeRemoved:
+		if change.Before != nil {
+			return fmt.Sprintf("- %s (%v)", change.Subject, change.Before)
+		}
+		return "- " + change.Subject
+	case ChangeMoved:
+		return fmt.Sprintf("> %s: %v -> %v", change.Subject, change.Before, change.After)
+	}
+
+	subject := change.Subject
+	if change.Field != "" {
+		subject += " " + change.Field
+	}
+	line := fmt.Sprintf("~ %s: %v -> %v", subject, change.Before, change.After)
+	if before, ok := change.Before.(int); ok {
+		if after, ok := change.After.(int); ok {
+			line += fmt.Sprintf(" (%+d)", after-before)
+		}
+	}
+	return line
+}
+
+type SaveDiff struct {
+	Changes []SaveChange `json:"changes"`
+}
+
+func (diff SaveDiff) Empty() bool {
+	return len(diff.Changes) == 0
+}
+
+// Text renders the diff grouped by category, one change per line.
+func (diff SaveDiff) Text() string {
+	if diff.Empty() {
+		return "No differences."
+	}
+
+	var lines []string
+	for _, category := range changeCategoryTitles {
+		var categoryLines []string
+		for _, change := range diff.Changes {
+			if change.Category == category.category {
+				categoryLines = append(categoryLines, "  "+change.String())
+			}
+		}
+		if len(categoryLines) > 0 {
+			lines = append(lines, category.title)
+			lines = append(lines, categoryLines...)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (diff SaveDiff) JSON() ([]byte, error) {
+	return json.MarshalIndent(diff, "", "    ")
+}
+
+// elestralDiffIgnoredFields are combat bookkeeping the game rewrites on its
+// own, which would otherwise drown out real changes.
+var elestralDiffIgnoredFields = map[string]bool{
+	"ID":                      true,
+	"CombatPos":               true,
+	"LastDodgeTime":           true,
+	"LastSuccessfulDodgeTime": true,
+	"TurnOrderUIIndex":        true,
+	"AbilityHitIndex":         true,
+	"SelectedAbilityIndex":    true,
+}
+
+type locatedElestral struct {
+	elestral *Elestral
+	location string
+}
+
+func elestralLabel(e *Elestral) string {
+	if e.Name != "" && e.Name != e.Species {
+		return fmt.Sprintf("%s %q", e.Species, e.Name)
+	}
+	return e.Species
+}
+
+// locateElestrals indexes the Elestrals in a save by ID.Hash. Repeated hashes
+// get a #n suffix so they still pair up in order.
+func locateElestrals(gameSave *GameSave) (map[string]locatedElestral, []string) {
+	located := map[string]locatedElestral{}
+	var order []string
+	Each(gameSave, nil, func(l Location, e *Elestral) {
+		key := e.ID.Hash
+		for n := 2; ; n++ {
+			if _, exists := located[key]; !exists {
+				break
+			}
+			key = fmt.Sprintf("%s#%d", e.ID.Hash, n)
+		}
+		located[key] = locatedElestral{elestral: e, location: l.String()}
+		order = append(order, key)
+	}, InParty, InStorage)
+	return located, order
+}
+
+func diffFields(category ChangeCategory, subject, hash string, before, after any, ignored map[string]bool) []SaveChange {
+	var changes []SaveChange
+	var walk func(prefix string, beforeValue, afterValue reflect.Value)
+	walk = func(prefix string, beforeValue, afterValue reflect.Value) {
+		for i := 0; i < beforeValue.NumField(); i++ {
+			field := beforeValue.Type().Field(i)
+			if !field.IsExported() || ignored[prefix+field.Name] {
+				continue
+			}
+
+			switch field.Type.Kind() {
+			case reflect.Pointer:
+				// Party slots are compared as Elestrals.
+				continue
+			case reflect.Struct:
+				// Nested values like stat stages are reported field by field.
+				walk(prefix+field.Name+".", beforeValue.Field(i), afterValue.Field(i))
+				continue
+			}
+
+			b := beforeValue.Field(i).Interface()
+			a := afterValue.Field(i).Interface()
+			if reflect.DeepEqual(b, a) {
+				continue
+			}
+			changes = append(changes, SaveChange{
+				Category: category,
+				Kind:     ChangeChanged,
+				Subject:  subject,
+				Hash:     hash,
+				Field:    prefix + field.Name,
+				Before:   b,
+				After:    a,
+			})
+		}
+	}
+	walk("", reflect.ValueOf(before), reflect.ValueOf(after))
+	return changes
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Diff reports what changed going from before to after.
+func Diff(before, after *GameSave) SaveDiff {
+	var diff SaveDiff
+	add := func(changes ...SaveChange) {
+		diff.Changes = append(diff.Changes, changes...)
+	}
+
+	for _, field := range []struct {
+		name          string
+		before, after string
+	}{
+		{"Scene", before.CurrentSceneName, after.CurrentSceneName},
+		{"Save version", DisplayVersion(before.SaveVersion), DisplayVersion(after.SaveVersion)},
+		{"Saved at", before.SaveTimestamp, after.SaveTimestamp},
+	} {
+		if field.before != field.after {
+			add(SaveChange{Category: CategorySave, Kind: ChangeChanged, Subject: field.name, Before: field.before, After: field.after})
+		}
+	}
+
+	add(diffFields(CategoryPlayer, "Player", "", before.ActivePlayerData, after.ActivePlayerData, nil)...)
+
+	beforeElestrals, beforeOrder := locateElestrals(before)
+	afterElestrals, afterOrder := locateElestrals(after)
+	for _, key := range beforeOrder {
+		old := beforeElestrals[key]
+		current, ok := afterElestrals[key]
+		if !ok {
+			add(SaveChange{Category: CategoryElestral, Kind: ChangeRemoved, Subject: elestralLabel(old.elestral), Hash: old.elestral.ID.Hash, Before: old.location})
+			continue
+		}
+
+		subject := elestralLabel(current.elestral)
+		if old.location != current.location {
+			add(SaveChange{Category: CategoryElestral, Kind: ChangeMoved, Subject: subject, Hash: current.elestral.ID.Hash, Before: old.location, After: current.location})
+		}
+		add(diffFields(CategoryElestral, subject, current.elestral.ID.Hash, *old.elestral, *current.elestral, elestralDiffIgnoredFields)...)
+	}
+	for _, key := range afterOrder {
+		if _, ok := beforeElestrals[key]; !ok {
+			current := afterElestrals[key]
+			add(SaveChange{Category: CategoryElestral, Kind: ChangeAdded, Subject: elestralLabel(current.elestral), Hash: current.elestral.ID.Hash, After: current.location})
+		}
+	}
+
+	beforeFlags := map[string]bool{}
+	for _, flag := range before.GameFlags {
+		beforeFlags[flag] = true
+	}
+	afterFlags := map[string]bool{}
+	for _, flag := range after.GameFlags {
+		afterFlags[flag] = true
+		if !beforeFlags[flag] {
+			add(SaveChange{Category: CategoryFlag, Kind: ChangeAdded, Subject: flag})
+		}
+	}
+	for _, flag := range before.GameFlags {
+		if !afterFlags[flag] {
+			add(SaveChange{Category: CategoryFlag, Kind: ChangeRemoved, Subject: flag})
+		}
+	}
+
+	boonCounts := func(boons ActiveBoons) map[string]int {
+		counts := map[string]int{}
+		for i, name := range boons.ActiveBoonNames {
+			count := 0
+			if i < len(boons.BoonUsageCounts) {
+				count = boons.BoonUsageCounts[i]
+			}
+			counts[name] = count
+		}
+		return counts
+	}
+	beforeBoons := boonCounts(before.ActiveBoons)
+	afterBoons := boonCounts(after.ActiveBoons)
+	for _, name := range sortedKeys(afterBoons) {
+		count, existed := beforeBoons[name]
+		if !existed {
+			add(SaveChange{Category: CategoryBoon, Kind: ChangeAdded, Subject: name})
+		} else if count != afterBoons[name] {
+			add(SaveChange{Category: CategoryBoon, Kind: ChangeChanged, Subject: name, Field: "uses", Before: count, After: afterBoons[name]})
+		}
+	}
+	for _, name := range sortedKeys(beforeBoons) {
+		if _, exists := afterBoons[name]; !exists {
+			add(SaveChange{Category: CategoryBoon, Kind: ChangeRemoved, Subject: name})
+		}
+	}
+
+	return diff
+}
+
+// DiffBanks reports Elestrals added to, removed from or changed in the bank.
+func DiffBanks(before, after *Bank) SaveDiff {
+	var diff SaveDiff
+	index := func(bank *Bank) (map[string]*Elestral, []string) {
+		located := map[string]*Elestral{}
+		var order []string
+		for _, e := range bank.Elestrals {
+			if e == nil {
+				continue
+			}
+			key := e.ID.Hash
+			for n := 2; located[key] != nil; n++ {
+				key = fmt.Sprintf("%s#%d", e.ID.Hash, n)
+			}
+			located[key] = e
+			order = append(order, key)
+		}
+		return located, order
+	}
+
+	beforeElestrals, beforeOrder := index(before)
+	afterElestrals, afterOrder := index(after)
+	for _, key := range beforeOrder {
+		old := beforeElestrals[key]
+		current, ok := afterElestrals[key]
+		if !ok {
+			diff.Changes = append(diff.Changes, SaveChange{Category: CategoryBank, Kind: ChangeRemoved, Subject: elestralLabel(old), Hash: old.ID.Hash})
+			continue
+		}
+		diff.Changes = append(diff.Changes, diffFields(CategoryBank, elestralLabel(current), current.ID.Hash, *old, *current, elestralDiffIgnoredFields)...)
+	}
+	for _, key := range afterOrder {
+		if _, ok := beforeElestrals[key]; !ok {
+			current := afterElestrals[key]
+			diff.Changes = append(diff.Changes, SaveChange{Category: CategoryBank, Kind: ChangeAdded, Subject: elestralLabel(current), Hash: current.ID.Hash})
+		}
+	}
+	return diff
+}
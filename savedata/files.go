@@ -0,0 +1,147 @@
+package savedata
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"pandorasbank/atomicfile"
+)
+
+// WriteOptions changes how WriteSave and WriteBank replace a file. The zero
+// value just writes it.
+type WriteOptions struct {
+	// BeforeWrite runs before an existing file is replaced, e.g. to back it
+	// up. If it returns an error nothing is written.
+	BeforeWrite func(path string) error
+}
+
+func (options WriteOptions) write(path string, data []byte, verify func([]byte) error) error {
+	if options.BeforeWrite != nil {
+		if _, err := os.Stat(path); err == nil {
+			if err := options.BeforeWrite(path); err != nil {
+				return err
+			}
+		}
+	}
+	return atomicfile.WriteFile(path, data, 0644, verify)
+}
+
+// ParseSave decodes a save file, remembering its layout so EncodeSave writes
+// it back the same way.
+func ParseSave(data []byte) (*GameSave, error) {
+	layout := detectSaveLayout(data)
+	data = bytes.TrimPrefix(data, utf8BOM)
+
+	var gameSave GameSave
+	if err := json.Unmarshal(data, &gameSave); err != nil {
+		return nil, fmt.Errorf("error parsing JSON: %w", err)
+	}
+	gameSave.layout = layout
+
+	return &gameSave, nil
+}
+
+// LoadSave reads and parses the save file at path.
+func LoadSave(path string) (*GameSave, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading file: %w", err)
+	}
+	return ParseSave(data)
+}
+
+// UseLayoutOf makes gameSave encode with the file layout other was read
+// with, for a save decoded from something other than its file.
+func (gameSave *GameSave) UseLayoutOf(other *GameSave) {
+	gameSave.layout = other.layout
+}
+
+// EncodeSave produces the file contents for gameSave. Saves newer than
+// NewestSaveVersion are refused.
+func EncodeSave(gameSave *GameSave) ([]byte, error) {
+	if err := CheckWritable(gameSave.SaveVersion); err != nil {
+		return nil, fmt.Errorf("refusing to write save: %w", err)
+	}
+
+	compact, err := Marshal(gameSave)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling JSON: %w", err)
+	}
+
+	layout := gameSave.layout
+	if gameSave.raw == nil {
+		layout = defaultSaveLayout
+	}
+	data, err := layout.format(compact)
+	if err != nil {
+		return nil, fmt.Errorf("error formatting JSON: %w", err)
+	}
+	return data, nil
+}
+
+// WriteSave encodes gameSave and atomically replaces the file at path.
+func WriteSave(path string, gameSave *GameSave, options WriteOptions) error {
+	data, err := EncodeSave(gameSave)
+	if err != nil {
+		return err
+	}
+	if err := options.write(path, data, VerifySave); err != nil {
+		return fmt.Errorf("error writing file: %w", err)
+	}
+	return nil
+}
+
+// VerifySave checks that data decodes as a save.
+func VerifySave(data []byte) error {
+	_, err := ParseSave(data)
+	return err
+}
+
+// NewBank returns an empty bank.
+func NewBank() *Bank {
+	return &Bank{Elestrals: []*Elestral{}}
+}
+
+// LoadBank reads the bank file at path. A missing file is an empty bank.
+func LoadBank(path string) (*Bank, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return NewBank(), nil
+		}
+		return nil, err
+	}
+
+	var bank Bank
+	if err := json.Unmarshal(data, &bank); err != nil {
+		return nil, err
+	}
+	return &bank, nil
+}
+
+// EncodeBank produces the file contents for bank. Banks newer than
+// NewestSaveVersion are refused.
+func EncodeBank(bank *Bank) ([]byte, error) {
+	if err := CheckWritable(bank.SaveVersion); err != nil {
+		return nil, fmt.Errorf("refusing to write bank: %w", err)
+	}
+	return json.MarshalIndent(bank, "", "    ")
+}
+
+// WriteBank encodes bank and atomically replaces the file at path.
+func WriteBank(path string, bank *Bank, options WriteOptions) error {
+	data, err := EncodeBank(bank)
+	if err != nil {
+		return err
+	}
+	return options.write(path, data, VerifyBank)
+}
+
+// VerifyBank checks that data decodes as a bank.
+func VerifyBank(data []byte) error {
+	var bank Bank
+	return json.Unmarshal(data, &bank)
+}
@@ -0,0 +1,344 @@
+package savedata
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+)
+
+// The game adds fields to its save format with most playtest patches. Every
+// save type keeps the exact JSON it was decoded from so that fields we don't
+// model are written back untouched, in their original order, and values we
+// didn't change keep their original spelling (e.g. 0.0 stays 0.0).
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// saveLayout describes how the original save file was laid out so a rewrite
+// only differs from it where values actually changed.
+type saveLayout struct {
+	indent          string
+	crlf            bool
+	trailingNewline bool
+	bom             bool
+}
+
+var defaultSaveLayout = saveLayout{indent: "    "}
+
+func detectSaveLayout(data []byte) saveLayout {
+	layout := saveLayout{}
+	if bytes.HasPrefix(data, utf8BOM) {
+		layout.bom = true
+		data = data[len(utf8BOM):]
+	}
+
+	trimmed := bytes.TrimRight(data, " \t\r\n")
+	layout.trailingNewline = len(trimmed) < len(data) && bytes.ContainsAny(data[len(trimmed):], "\n")
+	layout.crlf = bytes.Contains(data, []byte("\r\n"))
+
+	// The indent of the first nested line is one level of indentation.
+	if i := bytes.IndexByte(trimmed, '\n'); i >= 0 {
+		rest := trimmed[i+1:]
+		end := 0
+		for end < len(rest) && (rest[end] == ' ' || rest[end] == '\t') {
+			end++
+		}
+		layout.indent = string(rest[:end])
+		if layout.indent == "" {
+			layout.indent = defaultSaveLayout.indent
+		}
+	}
+
+	return layout
+}
+
+func (layout saveLayout) format(compact []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	if layout.bom {
+		buf.Write(utf8BOM)
+	}
+
+	if layout.indent == "" {
+		if err := json.Compact(&buf, compact); err != nil {
+			return nil, err
+		}
+	} else {
+		if err := json.Indent(&buf, compact, "", layout.indent); err != nil {
+			return nil, err
+		}
+	}
+
+	if layout.trailingNewline {
+		buf.WriteByte('\n')
+	}
+
+	data := buf.Bytes()
+	if layout.crlf {
+		// Raw newlines can't appear inside JSON strings, so this only touches layout.
+		data = bytes.ReplaceAll(data, []byte("\n"), []byte("\r\n"))
+	}
+	return data, nil
+}
+
+func Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+type jsonField struct {
+	key   string
+	value json.RawMessage
+}
+
+func objectFields(data []byte) ([]jsonField, bool) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	token, err := decoder.Token()
+	if delim, ok := token.(json.Delim); err != nil || !ok || delim != '{' {
+		return nil, false
+	}
+
+	var fields []jsonField
+	for decoder.More() {
+		token, err := decoder.Token()
+		key, ok := token.(string)
+		if err != nil || !ok {
+			return nil, false
+		}
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, false
+		}
+		fields = append(fields, jsonField{key: key, value: value})
+	}
+	return fields, true
+}
+
+func jsonEqual(a, b json.RawMessage) bool {
+	if bytes.Equal(a, b) {
+		return true
+	}
+	var va, vb any
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+// decodePreserving decodes data into v and returns a copy of data to be kept
+// alongside it for encodePreserving.
+func decodePreserving(data []byte, v any) (json.RawMessage, error) {
+	if err := json.Unmarshal(data, v); err != nil {
+		return nil, err
+	}
+	return append(json.RawMessage(nil), data...), nil
+}
+
+// isZeroJSON reports whether value is what the game would have omitted: null,
+// false, 0, "", an empty array or an object of only such values.
+func isZeroJSON(value json.RawMessage) bool {
+	var v any
+	if json.Unmarshal(value, &v) != nil {
+		return false
+	}
+	return isZeroValue(v)
+}
+
+func isZeroValue(v any) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case bool:
+		return !v
+	case float64:
+		return v == 0
+	case string:
+		return v == ""
+	case []any:
+		return len(v) == 0
+	case map[string]any:
+		for _, field := range v {
+			if !isZeroValue(field) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// encodePreserving encodes v and merges it into the object it was originally
+// decoded from: original keys keep their order, keys v doesn't know about are
+// kept as-is and unchanged values keep their original encoding. Keys the
+// original didn't have are appended at the end, unless they are still zero.
+func encodePreserving(v any, original json.RawMessage) ([]byte, error) {
+	fresh, err := Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	originalFields, ok := objectFields(original)
+	if !ok {
+		return fresh, nil
+	}
+	freshFields, ok := objectFields(fresh)
+	if !ok {
+		return fresh, nil
+	}
+
+	freshValues := make(map[string]json.RawMessage, len(freshFields))
+	for _, field := range freshFields {
+		freshValues[field.key] = field.value
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	writeField := func(key string, value json.RawMessage) error {
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		encodedKey, err := Marshal(key)
+		if err != nil {
+			return err
+		}
+		buf.Write(encodedKey)
+		buf.WriteByte(':')
+		buf.Write(value)
+		return nil
+	}
+
+	written := make(map[string]bool, len(originalFields))
+	for _, field := range originalFields {
+		value := field.value
+		if freshValue, known := freshValues[field.key]; known && !jsonEqual(field.value, freshValue) {
+			value = freshValue
+		}
+		if err := writeField(field.key, value); err != nil {
+			return nil, err
+		}
+		written[field.key] = true
+	}
+
+	for _, field := range freshFields {
+		if written[field.key] || isZeroJSON(field.value) {
+			continue
+		}
+		if err := writeField(field.key, field.value); err != nil {
+			return nil, err
+		}
+	}
+
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func (c *CombatPos) UnmarshalJSON(data []byte) error {
+	type plain CombatPos
+	raw, err := decodePreserving(data, (*plain)(c))
+	c.raw = raw
+	return err
+}
+
+func (c CombatPos) MarshalJSON() ([]byte, error) {
+	type plain CombatPos
+	return encodePreserving(plain(c), c.raw)
+}
+
+func (s *StatStages) UnmarshalJSON(data []byte) error {
+	type plain StatStages
+	raw, err := decodePreserving(data, (*plain)(s))
+	s.raw = raw
+	return err
+}
+
+func (s StatStages) MarshalJSON() ([]byte, error) {
+	type plain StatStages
+	return encodePreserving(plain(s), s.raw)
+}
+
+func (id *ElestralID) UnmarshalJSON(data []byte) error {
+	type plain ElestralID
+	raw, err := decodePreserving(data, (*plain)(id))
+	id.raw = raw
+	return err
+}
+
+func (id ElestralID) MarshalJSON() ([]byte, error) {
+	type plain ElestralID
+	return encodePreserving(plain(id), id.raw)
+}
+
+func (e *Elestral) UnmarshalJSON(data []byte) error {
+	type plain Elestral
+	raw, err := decodePreserving(data, (*plain)(e))
+	e.raw = raw
+	return err
+}
+
+func (e Elestral) MarshalJSON() ([]byte, error) {
+	type plain Elestral
+	return encodePreserving(plain(e), e.raw)
+}
+
+func (p *PlayerData) UnmarshalJSON(data []byte) error {
+	type plain PlayerData
+	raw, err := decodePreserving(data, (*plain)(p))
+	p.raw = raw
+	return err
+}
+
+func (p PlayerData) MarshalJSON() ([]byte, error) {
+	type plain PlayerData
+	return encodePreserving(plain(p), p.raw)
+}
+
+func (s *StorageEntry) UnmarshalJSON(data []byte) error {
+	type plain StorageEntry
+	raw, err := decodePreserving(data, (*plain)(s))
+	s.raw = raw
+	return err
+}
+
+func (s StorageEntry) MarshalJSON() ([]byte, error) {
+	type plain StorageEntry
+	return encodePreserving(plain(s), s.raw)
+}
+
+func (s *StorageBox) UnmarshalJSON(data []byte) error {
+	type plain StorageBox
+	raw, err := decodePreserving(data, (*plain)(s))
+	s.raw = raw
+	return err
+}
+
+func (s StorageBox) MarshalJSON() ([]byte, error) {
+	type plain StorageBox
+	return encodePreserving(plain(s), s.raw)
+}
+
+func (a *ActiveBoons) UnmarshalJSON(data []byte) error {
+	type plain ActiveBoons
+	raw, err := decodePreserving(data, (*plain)(a))
+	a.raw = raw
+	return err
+}
+
+func (a ActiveBoons) MarshalJSON() ([]byte, error) {
+	type plain ActiveBoons
+	return encodePreserving(plain(a), a.raw)
+}
+
+func (g *GameSave) UnmarshalJSON(data []byte) error {
+	type plain GameSave
+	raw, err := decodePreserving(data, (*plain)(g))
+	g.raw = raw
+	return err
+}
+
+func (g GameSave) MarshalJSON() ([]byte, error) {
+	type plain GameSave
+	return encodePreserving(plain(g), g.raw)
+}
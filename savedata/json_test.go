@@ -0,0 +1,135 @@
+package savedata
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// testSaveJSON is laid out the way json.Indent lays out the game's saves. It
+// has fields the save structs don't model, at the top level and nested, and
+// numbers spelled in ways a plain re-encode would change.
+const testSaveJSON = `{
+    "activePlayerData": {
+        "Name": "Caster",
+        "SpiritElement": 2,
+        "isMaleCharacter": false,
+        "Money": 1500,
+        "FocusedSlot": 0,
+        "Character0": {
+            "id": {
+                "serializedVersion": "2",
+                "Hash": "abc123"
+            },
+            "name": "Ember",
+            "species": "Emberlit",
+            "currentLevel": 12,
+            "lastDodgeTime": 0.0,
+            "IncomingDamageMultiplier": 1.00,
+            "futureField": {
+                "nested": [
+                    1,
+                    2.50
+                ]
+            },
+            "teamSlot": 0
+        },
+        "Character1": null,
+        "Character2": {},
+        "Character3": {},
+        "MaxCasterSP": 100,
+        "CasterSP": 100,
+        "BondMeter": 3,
+        "unknownPlayerField": "kept"
+    },
+    "storageBoxes": [
+        {
+            "entries": [
+                {
+                    "CharacterData": {
+                        "name": "Tidal",
+                        "species": "Tidewing",
+                        "currentLevel": 5,
+                        "lastSuccessfulDodgeTime": 2.50
+                    },
+                    "entryLocked": true
+                }
+            ],
+            "boxTheme": 4
+        }
+    ],
+    "gameFlags": [],
+    "worldState": {
+        "weather": "rain",
+        "time": 1.50
+    },
+    "saveVersion": "0.9.3",
+    "saveTimestamp": "2026-01-02T03:04:05"
+}
+`
+
+func TestEncodeSaveRoundTrip(t *testing.T) {
+	crlf := strings.ReplaceAll(testSaveJSON, "\n", "\r\n")
+	tests := []struct {
+		name string
+		data string
+		// edit changes the save before it is encoded; the encoding is
+		// expected to differ from data by replacing before with after.
+		edit          func(gameSave *GameSave)
+		before, after string
+	}{
+		{name: "LF", data: testSaveJSON},
+		{name: "CRLF", data: crlf},
+		{name: "CRLF and BOM", data: string(utf8BOM) + crlf},
+		{name: "compact", data: compactJSON(t, testSaveJSON)},
+		{
+			name:   "edit only changes the edited value",
+			data:   string(utf8BOM) + crlf,
+			edit:   func(gameSave *GameSave) { gameSave.ActivePlayerData.Character0.Name = "Blaze" },
+			before: `"name": "Ember"`,
+			after:  `"name": "Blaze"`,
+		},
+		{
+			name:   "edit in storage keeps the unknown fields around it",
+			data:   testSaveJSON,
+			edit:   func(gameSave *GameSave) { gameSave.StorageBoxes[0].Entries[0].CharacterData.CurrentLevel = 6 },
+			before: `"currentLevel": 5,`,
+			after:  `"currentLevel": 6,`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gameSave, err := ParseSave([]byte(test.data))
+			if err != nil {
+				t.Fatalf("ParseSave: %v", err)
+			}
+			want := test.data
+			if test.edit != nil {
+				test.edit(gameSave)
+				if !strings.Contains(want, test.before) {
+					t.Fatalf("test data doesn't contain %q", test.before)
+				}
+				want = strings.Replace(want, test.before, test.after, 1)
+			}
+
+			got, err := EncodeSave(gameSave)
+			if err != nil {
+				t.Fatalf("EncodeSave: %v", err)
+			}
+			if !bytes.Equal(got, []byte(want)) {
+				t.Errorf("encoding differs from the original:\ngot:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+func compactJSON(t *testing.T, data string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, []byte(data)); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
@@ -0,0 +1,371 @@
+package savedata
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrNoSuchSlot = errors.New("there is no such slot")
+	ErrSlotTaken  = errors.New("that slot already holds an Elestral")
+)
+
+// Area is the part of the save, or the bank, a Location is in.
+type Area int
+
+const (
+	InParty Area = iota
+	InStorage
+	InBank
+)
+
+func (area Area) String() string {
+	switch area {
+	case InParty:
+		return "party"
+	case InStorage:
+		return "storage"
+	default:
+		return "bank"
+	}
+}
+
+// Location addresses one place an Elestral can be kept, zero based: a party
+// slot (Index), a storage entry (Box and Entry) or a position in the bank
+// (Index). As a destination, the bank index may be one past the end to add
+// to the end of the bank.
+type Location struct {
+	Area  Area
+	Index int
+	Box   int
+	Entry int
+}
+
+func AtParty(slot int) Location {
+	return Location{Area: InParty, Index: slot}
+}
+
+func AtStorage(slot StorageSlot) Location {
+	return Location{Area: InStorage, Box: slot.Box, Entry: slot.Entry}
+}
+
+func AtBank(index int) Location {
+	return Location{Area: InBank, Index: index}
+}
+
+// BankEnd is the location that adds to the end of bank.
+func BankEnd(bank *Bank) Location {
+	return AtBank(len(bank.Elestrals))
+}
+
+// Storage returns the storage slot of a location in storage.
+func (l Location) Storage() StorageSlot {
+	return StorageSlot{Box: l.Box, Entry: l.Entry}
+}
+
+func (l Location) String() string {
+	switch l.Area {
+	case InParty:
+		return fmt.Sprintf("Party slot %d", l.Index+1)
+	case InStorage:
+		return l.Storage().String()
+	default:
+		return fmt.Sprintf("Bank %d", l.Index+1)
+	}
+}
+
+// holder returns the field that holds the Elestral at a party or storage
+// location.
+func holder(gameSave *GameSave, l Location) (**Elestral, error) {
+	switch l.Area {
+	case InParty:
+		player := &gameSave.ActivePlayerData
+		slots := []**Elestral{&player.Character0, &player.Character1, &player.Character2, &player.Character3}
+		if l.Index < 0 || l.Index >= len(slots) {
+			return nil, fmt.Errorf("%s: %w", l, ErrNoSuchSlot)
+		}
+		return slots[l.Index], nil
+	case InStorage:
+		if l.Box < 0 || l.Box >= len(gameSave.StorageBoxes) || l.Entry < 0 || l.Entry >= len(gameSave.StorageBoxes[l.Box].Entries) {
+			return nil, fmt.Errorf("%s: %w", l, ErrNoSuchSlot)
+		}
+		return &gameSave.StorageBoxes[l.Box].Entries[l.Entry].CharacterData, nil
+	}
+	return nil, fmt.Errorf("%s: %w", l, ErrNoSuchSlot)
+}
+
+// Get returns the Elestral at l, or nil if the slot is empty.
+func Get(gameSave *GameSave, bank *Bank, l Location) (*Elestral, error) {
+	if l.Area == InBank {
+		if l.Index < 0 || l.Index >= len(bank.Elestrals) {
+			return nil, fmt.Errorf("%s: %w", l, ErrNoSuchSlot)
+		}
+		return bank.Elestrals[l.Index], nil
+	}
+
+	slot, err := holder(gameSave, l)
+	if err != nil {
+		return nil, err
+	}
+	if (*slot).Empty() {
+		return nil, nil
+	}
+	return *slot, nil
+}
+
+// checkFree checks that an Elestral can be put at l.
+func checkFree(gameSave *GameSave, bank *Bank, l Location) error {
+	if l.Area == InBank {
+		if l.Index < 0 || l.Index > len(bank.Elestrals) {
+			return fmt.Errorf("%s: %w", l, ErrNoSuchSlot)
+		}
+		return nil
+	}
+
+	e, err := Get(gameSave, bank, l)
+	if err != nil {
+		return err
+	}
+	if e != nil {
+		return fmt.Errorf("%s: %w (%s)", l, ErrSlotTaken, e.Name)
+	}
+	return nil
+}
+
+// occupied returns the Elestral at l, which must not be empty.
+func occupied(gameSave *GameSave, bank *Bank, l Location) (*Elestral, error) {
+	e, err := Get(gameSave, bank, l)
+	if err != nil {
+		return nil, err
+	}
+	if e == nil {
+		return nil, fmt.Errorf("%s: %w", l, ErrEmptySlot)
+	}
+	return e, nil
+}
+
+// put stores a copy of e at l, which checkFree accepted, or over what is
+// there. Party and storage slots keep their Elestral so pointers to it see
+// the change.
+func put(gameSave *GameSave, bank *Bank, l Location, e Elestral, insert bool) {
+	if l.Area == InBank {
+		if insert {
+			bank.Elestrals = append(bank.Elestrals, nil)
+			copy(bank.Elestrals[l.Index+1:], bank.Elestrals[l.Index:])
+		}
+		bank.Elestrals[l.Index] = &e
+		return
+	}
+
+	slot, _ := holder(gameSave, l)
+	if *slot == nil {
+		*slot = &Elestral{}
+	}
+	**slot = e
+}
+
+// vacate empties l. Emptied party and storage slots hold a blank Elestral,
+// the way the game leaves them.
+func vacate(gameSave *GameSave, bank *Bank, l Location) {
+	if l.Area == InBank {
+		bank.Elestrals = append(bank.Elestrals[:l.Index], bank.Elestrals[l.Index+1:]...)
+		return
+	}
+
+	slot, _ := holder(gameSave, l)
+	if *slot != nil {
+		**slot = Elestral{}
+	}
+}
+
+// Move moves the Elestral at from to to, which must be empty unless it is in
+// the bank. Moving within the bank reorders it; to is where the Elestral ends
+// up.
+func Move(gameSave *GameSave, bank *Bank, from, to Location) error {
+	if from == to {
+		return nil
+	}
+	e, err := occupied(gameSave, bank, from)
+	if err != nil {
+		return err
+	}
+	if from.Area == InBank && to.Area == InBank {
+		// The bank is one shorter once the Elestral is taken out.
+		if to.Index < 0 || to.Index >= len(bank.Elestrals) {
+			return fmt.Errorf("%s: %w", to, ErrNoSuchSlot)
+		}
+	} else if err := checkFree(gameSave, bank, to); err != nil {
+		return err
+	}
+
+	moving := *e
+	vacate(gameSave, bank, from)
+	put(gameSave, bank, to, moving, true)
+	followFocus(gameSave, from, to)
+	tidyParty(gameSave, from, to)
+	return nil
+}
+
+// Copy puts a copy of the Elestral at from at to, which must be empty unless
+// it is in the bank.
+func Copy(gameSave *GameSave, bank *Bank, from, to Location) error {
+	e, err := occupied(gameSave, bank, from)
+	if err != nil {
+		return err
+	}
+	if err := checkFree(gameSave, bank, to); err != nil {
+		return err
+	}
+	put(gameSave, bank, to, *e, true)
+	tidyParty(gameSave, to)
+	return nil
+}
+
+// Swap exchanges the Elestrals at a and b. If one of them is empty it is a
+// Move into it.
+func Swap(gameSave *GameSave, bank *Bank, a, b Location) error {
+	if a == b {
+		return nil
+	}
+	ea, err := Get(gameSave, bank, a)
+	if err != nil {
+		return err
+	}
+	eb, err := Get(gameSave, bank, b)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case ea == nil && eb == nil:
+		return fmt.Errorf("%s and %s: %w", a, b, ErrEmptySlot)
+	case ea == nil:
+		return Move(gameSave, bank, b, a)
+	case eb == nil:
+		return Move(gameSave, bank, a, b)
+	}
+
+	va, vb := *ea, *eb
+	put(gameSave, bank, a, vb, false)
+	put(gameSave, bank, b, va, false)
+	if !followFocus(gameSave, a, b) {
+		followFocus(gameSave, b, a)
+	}
+	tidyParty(gameSave, a, b)
+	return nil
+}
+
+// Remove takes the Elestral at l out and returns it.
+func Remove(gameSave *GameSave, bank *Bank, l Location) (*Elestral, error) {
+	e, err := occupied(gameSave, bank, l)
+	if err != nil {
+		return nil, err
+	}
+	removed := *e
+	vacate(gameSave, bank, l)
+	tidyParty(gameSave, l)
+	return &removed, nil
+}
+
+// followFocus moves the party's focus from one party slot to another along
+// with the Elestral that moved, and reports whether it did.
+func followFocus(gameSave *GameSave, from, to Location) bool {
+	player := &gameSave.ActivePlayerData
+	if from.Area != InParty || to.Area != InParty || player.FocusedSlot != from.Index {
+		return false
+	}
+	player.FocusedSlot = to.Index
+	return true
+}
+
+// tidyParty compacts the party after an operation that touched any of
+// locations in it.
+func tidyParty(gameSave *GameSave, locations ...Location) {
+	for _, l := range locations {
+		if l.Area == InParty {
+			CompactParty(gameSave)
+			return
+		}
+	}
+}
+
+// CompactParty moves the party members up to fill empty slots, keeping their
+// order, and sets each one's TeamSlot to the slot it ends up in. FocusedSlot
+// follows the focused member, or if its slot was emptied stays where it is
+// within the party. Every operation on the party does this, so the party
+// never has a hole in the middle.
+func CompactParty(gameSave *GameSave) {
+	player := &gameSave.ActivePlayerData
+	slots := []**Elestral{&player.Character0, &player.Character1, &player.Character2, &player.Character3}
+
+	var members []Elestral
+	focused := -1
+	for i, slot := range slots {
+		if !(*slot).Empty() {
+			if i == player.FocusedSlot {
+				focused = len(members)
+			}
+			members = append(members, **slot)
+		}
+	}
+	switch {
+	case focused >= 0:
+		player.FocusedSlot = focused
+	case player.FocusedSlot >= len(members):
+		player.FocusedSlot = max(len(members)-1, 0)
+	case player.FocusedSlot < 0:
+		player.FocusedSlot = 0
+	}
+	for i, slot := range slots {
+		switch {
+		case i < len(members):
+			if *slot == nil {
+				*slot = &Elestral{}
+			}
+			**slot = members[i]
+			(*slot).TeamSlot = i
+		case *slot != nil:
+			**slot = Elestral{}
+		}
+	}
+}
+
+// Each calls fn for every Elestral in the areas of gameSave and bank, in the
+// order the app lists them. Empty slots are skipped; bank may be nil.
+func Each(gameSave *GameSave, bank *Bank, fn func(l Location, e *Elestral), areas ...Area) {
+	in := func(area Area) bool {
+		if len(areas) == 0 {
+			return true
+		}
+		for _, a := range areas {
+			if a == area {
+				return true
+			}
+		}
+		return false
+	}
+
+	if gameSave != nil && in(InParty) {
+		for i, e := range gameSave.ActivePlayerData.Party() {
+			if !e.Empty() {
+				fn(AtParty(i), e)
+			}
+		}
+	}
+	if gameSave != nil && in(InStorage) {
+		for boxIdx, box := range gameSave.StorageBoxes {
+			for entryIdx, entry := range box.Entries {
+				if !entry.CharacterData.Empty() {
+					fn(AtStorage(StorageSlot{Box: boxIdx, Entry: entryIdx}), entry.CharacterData)
+				}
+			}
+		}
+	}
+	if bank != nil && in(InBank) {
+		for i, e := range bank.Elestrals {
+			if e != nil {
+				fn(AtBank(i), e)
+			}
+		}
+	}
+}
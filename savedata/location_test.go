@@ -0,0 +1,253 @@
+package savedata
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// saveView is a save and bank by Elestral name, "" for an empty slot.
+type saveView struct {
+	Party   []string
+	Boxes   [][]string
+	Bank    []string
+	Focused int
+}
+
+func testElestral(name string) *Elestral {
+	if name == "" {
+		return &Elestral{}
+	}
+	return &Elestral{Name: name, Species: name, ID: ElestralID{Hash: name}}
+}
+
+// newTestSave builds a save and bank laid out as view.
+func newTestSave(view saveView) (*GameSave, *Bank) {
+	gameSave := &GameSave{}
+	player := &gameSave.ActivePlayerData
+	slots := []**Elestral{&player.Character0, &player.Character1, &player.Character2, &player.Character3}
+	for i, name := range view.Party {
+		*slots[i] = testElestral(name)
+		(*slots[i]).TeamSlot = i
+	}
+	player.FocusedSlot = view.Focused
+
+	for _, names := range view.Boxes {
+		var box StorageBox
+		for _, name := range names {
+			box.Entries = append(box.Entries, StorageEntry{CharacterData: testElestral(name)})
+		}
+		gameSave.StorageBoxes = append(gameSave.StorageBoxes, box)
+	}
+
+	bank := NewBank()
+	for _, name := range view.Bank {
+		bank.Elestrals = append(bank.Elestrals, testElestral(name))
+	}
+	return gameSave, bank
+}
+
+// viewOf describes gameSave and bank as a saveView, failing t if a party
+// member's TeamSlot isn't the slot it is in.
+func viewOf(t *testing.T, gameSave *GameSave, bank *Bank) saveView {
+	t.Helper()
+	name := func(e *Elestral) string {
+		if e.Empty() {
+			return ""
+		}
+		return e.Name
+	}
+
+	view := saveView{Focused: gameSave.ActivePlayerData.FocusedSlot, Bank: []string{}}
+	for i, e := range gameSave.ActivePlayerData.Party() {
+		view.Party = append(view.Party, name(e))
+		if !e.Empty() && e.TeamSlot != i {
+			t.Errorf("%s in party slot %d has team slot %d", e.Name, i+1, e.TeamSlot)
+		}
+	}
+	for _, box := range gameSave.StorageBoxes {
+		var names []string
+		for _, entry := range box.Entries {
+			names = append(names, name(entry.CharacterData))
+		}
+		view.Boxes = append(view.Boxes, names)
+	}
+	for _, e := range bank.Elestrals {
+		view.Bank = append(view.Bank, name(e))
+	}
+	return view
+}
+
+func TestMoveAndSwap(t *testing.T) {
+	start := saveView{
+		Party:   []string{"a", "b", "c", ""},
+		Boxes:   [][]string{{"d", "", "e"}},
+		Bank:    []string{"f", "g"},
+		Focused: 1,
+	}
+	box := func(box, entry int) Location {
+		return AtStorage(StorageSlot{Box: box, Entry: entry})
+	}
+
+	tests := []struct {
+		name    string
+		op      func(gameSave *GameSave, bank *Bank) error
+		want    saveView
+		wantErr error
+	}{
+		{
+			name: "move party to storage closes the gap",
+			op:   func(g *GameSave, b *Bank) error { return Move(g, b, AtParty(1), box(0, 1)) },
+			want: saveView{Party: []string{"a", "c", "", ""}, Boxes: [][]string{{"d", "b", "e"}}, Bank: []string{"f", "g"}, Focused: 1},
+		},
+		{
+			name: "move storage to party",
+			op:   func(g *GameSave, b *Bank) error { return Move(g, b, box(0, 0), AtParty(3)) },
+			want: saveView{Party: []string{"a", "b", "c", "d"}, Boxes: [][]string{{"", "", "e"}}, Bank: []string{"f", "g"}, Focused: 1},
+		},
+		{
+			name: "move party to the end of the bank",
+			op:   func(g *GameSave, b *Bank) error { return Move(g, b, AtParty(0), BankEnd(b)) },
+			want: saveView{Party: []string{"b", "c", "", ""}, Boxes: [][]string{{"d", "", "e"}}, Bank: []string{"f", "g", "a"}, Focused: 0},
+		},
+		{
+			name: "move bank to party",
+			op:   func(g *GameSave, b *Bank) error { return Move(g, b, AtBank(0), AtParty(3)) },
+			want: saveView{Party: []string{"a", "b", "c", "f"}, Boxes: [][]string{{"d", "", "e"}}, Bank: []string{"g"}, Focused: 1},
+		},
+		{
+			name: "move within the bank reorders it",
+			op:   func(g *GameSave, b *Bank) error { return Move(g, b, AtBank(0), AtBank(1)) },
+			want: saveView{Party: []string{"a", "b", "c", ""}, Boxes: [][]string{{"d", "", "e"}}, Bank: []string{"g", "f"}, Focused: 1},
+		},
+		{
+			name: "move to an empty party slot closes the gap",
+			op:   func(g *GameSave, b *Bank) error { return Move(g, b, AtParty(0), AtParty(3)) },
+			want: saveView{Party: []string{"b", "c", "a", ""}, Boxes: [][]string{{"d", "", "e"}}, Bank: []string{"f", "g"}, Focused: 0},
+		},
+		{
+			name:    "move to a taken slot",
+			op:      func(g *GameSave, b *Bank) error { return Move(g, b, AtParty(0), box(0, 0)) },
+			want:    start,
+			wantErr: ErrSlotTaken,
+		},
+		{
+			name:    "move from an empty slot",
+			op:      func(g *GameSave, b *Bank) error { return Move(g, b, box(0, 1), AtParty(3)) },
+			want:    start,
+			wantErr: ErrEmptySlot,
+		},
+		{
+			name:    "move to a box that doesn't exist",
+			op:      func(g *GameSave, b *Bank) error { return Move(g, b, AtParty(0), box(1, 0)) },
+			want:    start,
+			wantErr: ErrNoSuchSlot,
+		},
+		{
+			name: "swap within the party keeps the focus on its member",
+			op:   func(g *GameSave, b *Bank) error { return Swap(g, b, AtParty(1), AtParty(2)) },
+			want: saveView{Party: []string{"a", "c", "b", ""}, Boxes: [][]string{{"d", "", "e"}}, Bank: []string{"f", "g"}, Focused: 2},
+		},
+		{
+			name: "swap party with storage",
+			op:   func(g *GameSave, b *Bank) error { return Swap(g, b, AtParty(1), box(0, 2)) },
+			want: saveView{Party: []string{"a", "e", "c", ""}, Boxes: [][]string{{"d", "", "b"}}, Bank: []string{"f", "g"}, Focused: 1},
+		},
+		{
+			name: "swap with an empty storage slot moves and closes the gap",
+			op:   func(g *GameSave, b *Bank) error { return Swap(g, b, AtParty(0), box(0, 1)) },
+			want: saveView{Party: []string{"b", "c", "", ""}, Boxes: [][]string{{"d", "a", "e"}}, Bank: []string{"f", "g"}, Focused: 0},
+		},
+		{
+			name: "swap storage with bank",
+			op:   func(g *GameSave, b *Bank) error { return Swap(g, b, box(0, 0), AtBank(1)) },
+			want: saveView{Party: []string{"a", "b", "c", ""}, Boxes: [][]string{{"g", "", "e"}}, Bank: []string{"f", "d"}, Focused: 1},
+		},
+		{
+			name: "swap party with bank",
+			op:   func(g *GameSave, b *Bank) error { return Swap(g, b, AtParty(2), AtBank(0)) },
+			want: saveView{Party: []string{"a", "b", "f", ""}, Boxes: [][]string{{"d", "", "e"}}, Bank: []string{"c", "g"}, Focused: 1},
+		},
+		{
+			name:    "swap two empty slots",
+			op:      func(g *GameSave, b *Bank) error { return Swap(g, b, AtParty(3), box(0, 1)) },
+			want:    start,
+			wantErr: ErrEmptySlot,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gameSave, bank := newTestSave(start)
+			err := test.op(gameSave, bank)
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("error = %v, want %v", err, test.wantErr)
+			}
+			if got := viewOf(t, gameSave, bank); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got  %+v\nwant %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCompactParty(t *testing.T) {
+	tests := []struct {
+		name    string
+		party   []string
+		focused int
+		want    []string
+		// wantFocused is FocusedSlot afterwards.
+		wantFocused int
+	}{
+		{
+			name:        "already compact",
+			party:       []string{"a", "b", "", ""},
+			focused:     1,
+			want:        []string{"a", "b", "", ""},
+			wantFocused: 1,
+		},
+		{
+			name:        "gaps close and the focus follows its member",
+			party:       []string{"", "a", "", "b"},
+			focused:     3,
+			want:        []string{"a", "b", "", ""},
+			wantFocused: 1,
+		},
+		{
+			name:        "focus on an emptied slot past the party is clamped",
+			party:       []string{"a", "", "", ""},
+			focused:     3,
+			want:        []string{"a", "", "", ""},
+			wantFocused: 0,
+		},
+		{
+			name:        "empty party",
+			party:       []string{"", "", "", ""},
+			focused:     2,
+			want:        []string{"", "", "", ""},
+			wantFocused: 0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gameSave, bank := newTestSave(saveView{Party: test.party, Focused: test.focused})
+			CompactParty(gameSave)
+			got := viewOf(t, gameSave, bank)
+			if !reflect.DeepEqual(got.Party, test.want) || got.Focused != test.wantFocused {
+				t.Errorf("party = %q focused %d, want %q focused %d", got.Party, got.Focused, test.want, test.wantFocused)
+			}
+		})
+	}
+}
+
+func TestCompactPartyNilSlots(t *testing.T) {
+	gameSave, bank := newTestSave(saveView{Party: []string{"a", "", "b", ""}})
+	gameSave.ActivePlayerData.Character1 = nil
+	gameSave.ActivePlayerData.Character3 = nil
+
+	CompactParty(gameSave)
+	if got, want := viewOf(t, gameSave, bank).Party, []string{"a", "b", "", ""}; !reflect.DeepEqual(got, want) {
+		t.Errorf("party = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,209 @@
+package savedata
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// NewestSaveVersion is the newest saveVersion the save structs are modelled
+// on. Anything newer may hold data they can't represent, so it is refused
+// rather than rewritten. Bump it, and register any migration the new build
+// needs, once the structs have been checked against a save from that build.
+const NewestSaveVersion = "1.0"
+
+// LegacySaveVersion is the saveVersion of saves (and bank files) written
+// before a version was recorded.
+const LegacySaveVersion = ""
+
+// saveMigration adapts data written by an older build of the game to what the
+// save structs expect. The game owns saveVersion, so a migration never changes
+// it: each one checks the data itself and changes nothing once it has been
+// applied. Each step returns a human readable line per change it made.
+type saveMigration struct {
+	// before limits the migration to data whose saveVersion, as the game
+	// wrote it, is older than before. Empty applies it to every version.
+	before      string
+	description string
+
+	// save runs once per save, elestral once per Elestral in a save or bank.
+	save     func(gameSave *GameSave) []string
+	elestral func(e *Elestral) []string
+}
+
+func (migration saveMigration) String() string {
+	if migration.before == "" {
+		return migration.description
+	}
+	return fmt.Sprintf("%s (saves before %s)", migration.description, migration.before)
+}
+
+func (migration saveMigration) appliesTo(version string) bool {
+	return migration.before == "" || version == LegacySaveVersion || CompareVersions(version, migration.before) < 0
+}
+
+// saveMigrations run in order on every save and bank that is loaded, each one
+// only on the versions it applies to, so data from an old build is brought up
+// one step at a time. Keep them ordered by before.
+var saveMigrations = []saveMigration{
+	{
+		// Version independent: builds of every version so far can leave these
+		// at zero, and a save with no SP can't be played, so the data itself
+		// says whether it is needed.
+		description: "Add caster SP and bond meter",
+		save: func(gameSave *GameSave) []string {
+			player := &gameSave.ActivePlayerData
+			if player.MaxSp != 0 {
+				return nil
+			}
+			player.MaxSp = 100
+			player.CurrentSp = 100
+			player.BondMeter = 3
+			return []string{"Set caster SP to 100/100 and bond meter to 3"}
+		},
+	},
+}
+
+type MigrationReport struct {
+	Version string
+	Steps   []string
+	Changes []string
+}
+
+func (report MigrationReport) Migrated() bool {
+	return len(report.Changes) > 0
+}
+
+func (report MigrationReport) String() string {
+	lines := []string{fmt.Sprintf("Updated data written by save version %s.", DisplayVersion(report.Version))}
+	lines = append(lines, report.Steps...)
+	for _, change := range report.Changes {
+		lines = append(lines, "- "+change)
+	}
+	return strings.Join(lines, "\n")
+}
+
+type UnsupportedSaveVersionError struct {
+	Version string
+}
+
+func (err *UnsupportedSaveVersionError) Error() string {
+	return fmt.Sprintf("save version %s is newer than this version of Pandora's Bank supports (%s)",
+		DisplayVersion(err.Version), NewestSaveVersion)
+}
+
+func DisplayVersion(version string) string {
+	if version == LegacySaveVersion {
+		return "(none)"
+	}
+	return version
+}
+
+// CompareVersions compares dotted versions numerically where possible,
+// falling back to a plain string comparison per part.
+func CompareVersions(a, b string) int {
+	aParts := strings.Split(a, ".")
+	bParts := strings.Split(b, ".")
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		// A missing part counts as zero, so 1.0 and 1.0.0 are the same.
+		aPart, bPart := "0", "0"
+		if i < len(aParts) {
+			aPart = aParts[i]
+		}
+		if i < len(bParts) {
+			bPart = bParts[i]
+		}
+
+		aNum, aErr := strconv.Atoi(aPart)
+		bNum, bErr := strconv.Atoi(bPart)
+		if aErr == nil && bErr == nil {
+			if aNum != bNum {
+				if aNum < bNum {
+					return -1
+				}
+				return 1
+			}
+			continue
+		}
+		if c := strings.Compare(aPart, bPart); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func migrateElestrals(migration saveMigration, elestrals []*Elestral) []string {
+	if migration.elestral == nil {
+		return nil
+	}
+
+	var changes []string
+	for _, e := range elestrals {
+		if e == nil || e.Species == "" {
+			continue
+		}
+		for _, change := range migration.elestral(e) {
+			changes = append(changes, fmt.Sprintf("%s (%s): %s", e.Name, e.Species, change))
+		}
+	}
+	return changes
+}
+
+// MigrateSave runs every migration that applies to gameSave's version. Saves
+// newer than NewestSaveVersion are left untouched and an
+// *UnsupportedSaveVersionError is returned.
+func MigrateSave(gameSave *GameSave) (MigrationReport, error) {
+	report := MigrationReport{Version: gameSave.SaveVersion}
+	if err := CheckWritable(gameSave.SaveVersion); err != nil {
+		return report, err
+	}
+
+	for _, migration := range saveMigrations {
+		if !migration.appliesTo(gameSave.SaveVersion) {
+			continue
+		}
+		var changes []string
+		if migration.save != nil {
+			changes = append(changes, migration.save(gameSave)...)
+		}
+		changes = append(changes, migrateElestrals(migration, gameSave.Elestrals())...)
+		report.add(migration, changes)
+	}
+
+	return report, nil
+}
+
+// MigrateBank runs the Elestral migrations on the Elestrals in bank, the same
+// way MigrateSave does for a save.
+func MigrateBank(bank *Bank) (MigrationReport, error) {
+	report := MigrationReport{Version: bank.SaveVersion}
+	if err := CheckWritable(bank.SaveVersion); err != nil {
+		return report, err
+	}
+
+	for _, migration := range saveMigrations {
+		if migration.appliesTo(bank.SaveVersion) {
+			report.add(migration, migrateElestrals(migration, bank.Elestrals))
+		}
+	}
+
+	return report, nil
+}
+
+func (report *MigrationReport) add(migration saveMigration, changes []string) {
+	if len(changes) == 0 {
+		return
+	}
+	report.Steps = append(report.Steps, migration.String())
+	report.Changes = append(report.Changes, changes...)
+}
+
+// CheckWritable refuses versions newer than NewestSaveVersion, so data we
+// can't represent is never rewritten. The version itself is always written
+// back as it was read.
+func CheckWritable(version string) error {
+	if version != LegacySaveVersion && CompareVersions(version, NewestSaveVersion) > 0 {
+		return &UnsupportedSaveVersionError{Version: version}
+	}
+	return nil
+}
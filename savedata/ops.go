@@ -0,0 +1,192 @@
+package savedata
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrStorageFull = errors.New("no available slots in storage boxes")
+	ErrPartyFull   = errors.New("no free party slot")
+	ErrBoxFull     = errors.New("no free slot in that box")
+	ErrEmptySlot   = errors.New("there is no Elestral in that slot")
+	ErrEmptyName   = errors.New("name can't be empty")
+)
+
+// StorageSlot is a place in the storage boxes, zero based.
+type StorageSlot struct {
+	Box   int
+	Entry int
+}
+
+func (slot StorageSlot) String() string {
+	return fmt.Sprintf("Storage Box %d slot %d", slot.Box+1, slot.Entry+1)
+}
+
+// FindFreeSlot returns the first empty storage slot.
+func FindFreeSlot(gameSave *GameSave) (StorageSlot, bool) {
+	for box := range gameSave.StorageBoxes {
+		if slot, found := FreeSlotInBox(gameSave, box); found {
+			return slot, true
+		}
+	}
+	return StorageSlot{Box: -1, Entry: -1}, false
+}
+
+// FreeSlotInBox returns the first empty entry of storage box box.
+func FreeSlotInBox(gameSave *GameSave, box int) (StorageSlot, bool) {
+	if box >= 0 && box < len(gameSave.StorageBoxes) {
+		for entryIdx, entry := range gameSave.StorageBoxes[box].Entries {
+			if entry.CharacterData.Empty() {
+				return StorageSlot{Box: box, Entry: entryIdx}, true
+			}
+		}
+	}
+	return StorageSlot{Box: -1, Entry: -1}, false
+}
+
+// CopyToBank adds a copy of e to the end of the bank.
+func CopyToBank(bank *Bank, e *Elestral) {
+	elesCopy := *e
+	bank.Elestrals = append(bank.Elestrals, &elesCopy)
+}
+
+// CopyToStorage puts a copy of e in the first free storage slot and returns
+// where it went.
+func CopyToStorage(gameSave *GameSave, e *Elestral) (StorageSlot, error) {
+	slot, found := FindFreeSlot(gameSave)
+	if !found {
+		return slot, ErrStorageFull
+	}
+
+	elesCopy := *e
+	gameSave.StorageBoxes[slot.Box].Entries[slot.Entry].CharacterData = &elesCopy
+	return slot, nil
+}
+
+// ExportToBank moves the Elestral at from, a party or storage slot, to the
+// end of the bank, leaving the slot empty.
+func ExportToBank(gameSave *GameSave, bank *Bank, from Location) error {
+	return Move(gameSave, bank, from, BankEnd(bank))
+}
+
+// ImportFromBank moves the bank's Elestral at index into the first free
+// storage slot.
+func ImportFromBank(gameSave *GameSave, bank *Bank, index int) (StorageSlot, error) {
+	if _, err := occupied(gameSave, bank, AtBank(index)); err != nil {
+		return StorageSlot{Box: -1, Entry: -1}, err
+	}
+
+	slot, found := FindFreeSlot(gameSave)
+	if !found {
+		return slot, ErrStorageFull
+	}
+	return slot, Move(gameSave, bank, AtBank(index), AtStorage(slot))
+}
+
+// FreePartySlot returns the first empty party slot.
+func FreePartySlot(gameSave *GameSave) (int, bool) {
+	for i, e := range gameSave.ActivePlayerData.Party() {
+		if e.Empty() {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// MoveToParty moves the Elestral at from, in storage or the bank, into the
+// first free party slot and returns that slot's index.
+func MoveToParty(gameSave *GameSave, bank *Bank, from Location) (int, error) {
+	if _, err := occupied(gameSave, bank, from); err != nil {
+		return -1, err
+	}
+
+	slot, found := FreePartySlot(gameSave)
+	if !found {
+		return -1, ErrPartyFull
+	}
+	return slot, Move(gameSave, bank, from, AtParty(slot))
+}
+
+// MoveToBox moves the Elestral at from into the first free entry of storage
+// box box and returns where it went.
+func MoveToBox(gameSave *GameSave, bank *Bank, from Location, box int) (StorageSlot, error) {
+	if _, err := occupied(gameSave, bank, from); err != nil {
+		return StorageSlot{Box: -1, Entry: -1}, err
+	}
+
+	slot, found := FreeSlotInBox(gameSave, box)
+	if !found {
+		return slot, fmt.Errorf("Storage Box %d: %w", box+1, ErrBoxFull)
+	}
+	return slot, Move(gameSave, bank, from, AtStorage(slot))
+}
+
+// FreeSlots counts the empty entries of storage box box.
+func FreeSlots(gameSave *GameSave, box int) int {
+	free := 0
+	for _, entry := range gameSave.StorageBoxes[box].Entries {
+		if entry.CharacterData.Empty() {
+			free++
+		}
+	}
+	return free
+}
+
+// PartySize counts the party members.
+func PartySize(gameSave *GameSave) int {
+	size := 0
+	for _, e := range gameSave.ActivePlayerData.Party() {
+		if !e.Empty() {
+			size++
+		}
+	}
+	return size
+}
+
+// PartyFull reports whether every party slot holds an Elestral.
+func PartyFull(gameSave *GameSave) bool {
+	_, found := FreePartySlot(gameSave)
+	return !found
+}
+
+func Rename(e *Elestral, name string) error {
+	if name == "" {
+		return ErrEmptyName
+	}
+	e.Name = name
+	return nil
+}
+
+// Release removes the bank's Elestral at index and returns it.
+func Release(bank *Bank, index int) (*Elestral, error) {
+	return Remove(nil, bank, AtBank(index))
+}
+
+// Locations describes where an Elestral with the given hash is in gameSave
+// and bank.
+func Locations(gameSave *GameSave, bank *Bank, hash string) []string {
+	if hash == "" {
+		return nil
+	}
+
+	var locations []string
+	for i, e := range gameSave.ActivePlayerData.Party() {
+		if !e.Empty() && e.ID.Hash == hash {
+			locations = append(locations, fmt.Sprintf("party slot %d", i+1))
+		}
+	}
+	for boxIdx, box := range gameSave.StorageBoxes {
+		for _, entry := range box.Entries {
+			if e := entry.CharacterData; !e.Empty() && e.ID.Hash == hash {
+				locations = append(locations, fmt.Sprintf("Storage Box %d", boxIdx+1))
+			}
+		}
+	}
+	for _, e := range bank.Elestrals {
+		if e != nil && e.ID.Hash == hash {
+			locations = append(locations, "the bank")
+		}
+	}
+	return locations
+}
@@ -0,0 +1,192 @@
+package savedata
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// SortKey is something Organise can order storage by.
+type SortKey string
+
+const (
+	BySpecies SortKey = "species"
+	// ByElement orders by element, then sub-element.
+	ByElement SortKey = "element"
+	// ByLevel puts the highest level first.
+	ByLevel SortKey = "level"
+	// ByStellar puts Stellar Elestrals first.
+	ByStellar SortKey = "stellar"
+	ByName    SortKey = "name"
+)
+
+// SortKeys lists every key, in the order the app offers them.
+var SortKeys = []SortKey{BySpecies, ByElement, ByLevel, ByStellar, ByName}
+
+func (key SortKey) Label() string {
+	switch key {
+	case BySpecies:
+		return "Species"
+	case ByElement:
+		return "Element / Sub-element"
+	case ByLevel:
+		return "Level (highest first)"
+	case ByStellar:
+		return "Stellar first"
+	case ByName:
+		return "Name"
+	}
+	return string(key)
+}
+
+// ParseSortKey returns the key called name, ignoring case.
+func ParseSortKey(name string) (SortKey, error) {
+	for _, key := range SortKeys {
+		if strings.EqualFold(name, string(key)) {
+			return key, nil
+		}
+	}
+	names := make([]string, len(SortKeys))
+	for i, key := range SortKeys {
+		names[i] = string(key)
+	}
+	return "", fmt.Errorf("unknown sort %q, expected one of %s", name, strings.Join(names, ", "))
+}
+
+func (key SortKey) compare(a, b *Elestral) int {
+	switch key {
+	case BySpecies:
+		return cmp.Compare(strings.ToLower(a.Species), strings.ToLower(b.Species))
+	case ByElement:
+		return cmp.Or(cmp.Compare(a.Element, b.Element), cmp.Compare(a.SubElement, b.SubElement))
+	case ByLevel:
+		return cmp.Compare(b.CurrentLevel, a.CurrentLevel)
+	case ByStellar:
+		switch {
+		case a.IsStellar == b.IsStellar:
+			return 0
+		case a.IsStellar:
+			return -1
+		default:
+			return 1
+		}
+	case ByName:
+		return cmp.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	}
+	return 0
+}
+
+// OrganiseOptions say how Organise lays out storage.
+type OrganiseOptions struct {
+	By SortKey
+	// ThenBy breaks ties in By, if set. Elestrals that still tie keep the
+	// order they were in.
+	ThenBy SortKey
+	// EmptyAtEnd packs the Elestrals into the first entries so the empty
+	// ones are all at the end of the last boxes. Otherwise the empty entries
+	// stay where they are.
+	EmptyAtEnd bool
+}
+
+// Layout is the storage boxes as Organise would leave them:
+// layout[box][entry] is the Elestral in that entry, or nil if it is empty.
+// The Elestrals are the save's own, so a layout goes stale once the save is
+// changed.
+type Layout [][]*Elestral
+
+// PlanOrganise works out how Organise would lay out the storage of gameSave,
+// without changing it.
+func PlanOrganise(gameSave *GameSave, options OrganiseOptions) (Layout, error) {
+	for _, key := range []SortKey{options.By, options.ThenBy} {
+		if key == "" {
+			continue
+		}
+		if _, err := ParseSortKey(string(key)); err != nil {
+			return nil, err
+		}
+	}
+
+	var elestrals []*Elestral
+	Each(gameSave, nil, func(l Location, e *Elestral) {
+		elestrals = append(elestrals, e)
+	}, InStorage)
+	slices.SortStableFunc(elestrals, func(a, b *Elestral) int {
+		return cmp.Or(options.By.compare(a, b), options.ThenBy.compare(a, b))
+	})
+
+	layout := make(Layout, len(gameSave.StorageBoxes))
+	next := 0
+	for boxIdx, box := range gameSave.StorageBoxes {
+		layout[boxIdx] = make([]*Elestral, len(box.Entries))
+		for entryIdx, entry := range box.Entries {
+			if !options.EmptyAtEnd && entry.CharacterData.Empty() {
+				continue
+			}
+			if next < len(elestrals) {
+				layout[boxIdx][entryIdx] = elestrals[next]
+				next++
+			}
+		}
+	}
+	return layout, nil
+}
+
+// Moved counts the Elestrals that layout puts in a different entry of
+// gameSave than they are in now.
+func (layout Layout) Moved(gameSave *GameSave) int {
+	moved := 0
+	for boxIdx, box := range gameSave.StorageBoxes {
+		for entryIdx, entry := range box.Entries {
+			if e := layout[boxIdx][entryIdx]; e != nil && e != entry.CharacterData {
+				moved++
+			}
+		}
+	}
+	return moved
+}
+
+// ApplyLayout rearranges the storage of gameSave as layout, which must have
+// been planned for it as it is now.
+func ApplyLayout(gameSave *GameSave, layout Layout) error {
+	if len(layout) != len(gameSave.StorageBoxes) {
+		return fmt.Errorf("the layout has %d boxes but the save has %d", len(layout), len(gameSave.StorageBoxes))
+	}
+	// The layout points at the entries being overwritten, so copy them all first.
+	values := make([][]*Elestral, len(layout))
+	for boxIdx, box := range layout {
+		if len(box) != len(gameSave.StorageBoxes[boxIdx].Entries) {
+			return fmt.Errorf("the layout of Storage Box %d doesn't match the save", boxIdx+1)
+		}
+		values[boxIdx] = make([]*Elestral, len(box))
+		for entryIdx, e := range box {
+			if e != nil {
+				copied := *e
+				values[boxIdx][entryIdx] = &copied
+			}
+		}
+	}
+
+	for boxIdx, box := range values {
+		for entryIdx, e := range box {
+			at := AtStorage(StorageSlot{Box: boxIdx, Entry: entryIdx})
+			if e != nil {
+				put(gameSave, nil, at, *e, false)
+			} else {
+				vacate(gameSave, nil, at)
+			}
+		}
+	}
+	return nil
+}
+
+// Organise sorts the Elestrals in storage across all boxes and returns how
+// many of them moved.
+func Organise(gameSave *GameSave, options OrganiseOptions) (int, error) {
+	layout, err := PlanOrganise(gameSave, options)
+	if err != nil {
+		return 0, err
+	}
+	moved := layout.Moved(gameSave)
+	return moved, ApplyLayout(gameSave, layout)
+}
@@ -0,0 +1,505 @@
+package savedata
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// RepairReport describes what RepairSave had to change to read a save and
+// what it got out of it.
+type RepairReport struct {
+	// KeptBytes of TotalBytes were used; the rest was cut off or unreadable.
+	KeptBytes  int      `json:"keptBytes"`
+	TotalBytes int      `json:"totalBytes"`
+	Changes    []string `json:"changes"`
+	Salvaged   []string `json:"salvaged"`
+}
+
+func (report RepairReport) String() string {
+	var lines []string
+	if report.KeptBytes < report.TotalBytes {
+		lines = append(lines, fmt.Sprintf("Only the first %d of %d bytes could be read; anything after that is lost.", report.KeptBytes, report.TotalBytes))
+	}
+	if len(report.Changes) > 0 {
+		lines = append(lines, "Repairs:")
+		for _, change := range report.Changes {
+			lines = append(lines, "- "+change)
+		}
+	}
+	if len(report.Salvaged) > 0 {
+		lines = append(lines, "Recovered:")
+		for _, salvaged := range report.Salvaged {
+			lines = append(lines, "- "+salvaged)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// RepairSave recovers what it can from a save that doesn't parse: a file cut
+// off mid-write is closed after its last complete value, values of the wrong
+// type are converted or dropped, and null or missing party and storage slots
+// become empty slots. The migrations are run on the result as they are when a
+// save is opened. It should be written to a new file so the original is kept.
+func RepairSave(data []byte) (*GameSave, RepairReport, error) {
+	report := RepairReport{TotalBytes: len(data)}
+	layout := detectSaveLayout(data)
+	body := bytes.TrimPrefix(data, utf8BOM)
+	offset := len(data) - len(body)
+
+	complete, kept, ok := completeJSON(body)
+	if !ok {
+		return nil, report, errors.New("no part of the file could be read as a save")
+	}
+	report.KeptBytes = offset + kept
+	if kept < len(bytes.TrimRight(body, " \t\r\n")) {
+		report.Changes = append(report.Changes, fmt.Sprintf("Ignored everything after byte %d, where the last complete value ends", report.KeptBytes))
+	}
+
+	repairer := typeRepairer{}
+	fixed, keep := repairer.value(complete, reflect.TypeOf(GameSave{}), "")
+	if !keep || !hasKnownField(fixed, reflect.TypeOf(GameSave{})) {
+		return nil, report, errors.New("the file does not look like an Elestrals Awakened save")
+	}
+	report.Changes = append(report.Changes, repairer.changes...)
+
+	gameSave, err := ParseSave(fixed)
+	if err != nil {
+		return nil, report, fmt.Errorf("the save still can't be read after repairing it: %w", err)
+	}
+	gameSave.layout = layout
+
+	player := &gameSave.ActivePlayerData
+	var nullSlots []string
+	for i, slot := range []**Elestral{&player.Character0, &player.Character1, &player.Character2, &player.Character3} {
+		if *slot == nil {
+			*slot = &Elestral{}
+			nullSlots = append(nullSlots, strconv.Itoa(i+1))
+		}
+	}
+	if len(nullSlots) > 0 {
+		report.Changes = append(report.Changes, fmt.Sprintf("Party slots %s were null or missing and are now empty", strings.Join(nullSlots, ", ")))
+	}
+	nullEntries := 0
+	for boxIdx := range gameSave.StorageBoxes {
+		for entryIdx := range gameSave.StorageBoxes[boxIdx].Entries {
+			entry := &gameSave.StorageBoxes[boxIdx].Entries[entryIdx]
+			if entry.CharacterData == nil {
+				entry.CharacterData = &Elestral{}
+				nullEntries++
+			}
+		}
+	}
+	if nullEntries > 0 {
+		report.Changes = append(report.Changes, fmt.Sprintf("%d storage slots had no Elestral data and are now empty", nullEntries))
+	}
+
+	migration, err := MigrateSave(gameSave)
+	if err != nil {
+		return nil, report, err
+	}
+	if migration.Migrated() {
+		report.Changes = append(report.Changes, fmt.Sprintf("Updated data written by save version %s", DisplayVersion(migration.Version)))
+		report.Changes = append(report.Changes, migration.Changes...)
+	}
+
+	report.Salvaged = append(report.Salvaged, fmt.Sprintf("Player %q with %d money", player.Name, player.Money))
+	party := gameSave.PartySpecies()
+	if len(party) > 0 {
+		report.Salvaged = append(report.Salvaged, fmt.Sprintf("%d party Elestrals: %s", len(party), strings.Join(party, ", ")))
+	} else {
+		report.Salvaged = append(report.Salvaged, "No party Elestrals")
+	}
+	storageCount := 0
+	for _, box := range gameSave.StorageBoxes {
+		for _, entry := range box.Entries {
+			if !entry.CharacterData.Empty() {
+				storageCount++
+			}
+		}
+	}
+	report.Salvaged = append(report.Salvaged, fmt.Sprintf("%d Elestrals in %d storage boxes", storageCount, len(gameSave.StorageBoxes)))
+	report.Salvaged = append(report.Salvaged, fmt.Sprintf("%d game flags and %d boons", len(gameSave.GameFlags), len(gameSave.ActiveBoons.ActiveBoonNames)))
+
+	return gameSave, report, nil
+}
+
+// completeJSON cuts data back to the end of its last complete value and
+// closes every object and array still open there, for a file that was cut off
+// or filled with junk part way through. It returns the completed JSON and how
+// many bytes of data it kept.
+func completeJSON(data []byte) ([]byte, int, bool) {
+	const (
+		expectFirst = iota // the first key or value, or the end of the container
+		expectKey
+		expectColon
+		expectValue
+		expectComma // a value just ended
+	)
+	type frame struct {
+		open  byte
+		state int
+	}
+
+	var stack []frame
+	cut := -1
+	var cutStack []frame
+	markCut := func(i int) {
+		cut = i
+		cutStack = append(cutStack[:0], stack...)
+	}
+
+	// valueDone moves the parent on once a value ends at i and reports
+	// whether it was the top-level value.
+	valueDone := func(i int) bool {
+		if len(stack) == 0 {
+			return true
+		}
+		stack[len(stack)-1].state = expectComma
+		markCut(i)
+		return false
+	}
+
+	i := 0
+	for i < len(data) {
+		c := data[i]
+		if c == ' ' || c == '\t' || c == '\r' || c == '\n' {
+			i++
+			continue
+		}
+
+		state, open := expectValue, byte(0)
+		if len(stack) > 0 {
+			state, open = stack[len(stack)-1].state, stack[len(stack)-1].open
+		}
+		wantKey := state == expectKey || (state == expectFirst && open == '{')
+		wantValue := state == expectValue || (state == expectFirst && open == '[')
+
+		switch {
+		case c == '"' && (wantKey || wantValue):
+			end := i + 1
+			for end < len(data) && data[end] != '"' {
+				if data[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(data) || !json.Valid(data[i:end+1]) {
+				goto done
+			}
+			i = end + 1
+			if wantKey {
+				stack[len(stack)-1].state = expectColon
+			} else if valueDone(i) {
+				return data[:i], i, true
+			}
+		case (c == '{' || c == '[') && wantValue:
+			stack = append(stack, frame{open: c, state: expectFirst})
+			i++
+			markCut(i)
+		case (c == '}' && open == '{' || c == ']' && open == '[') && (state == expectFirst || state == expectComma):
+			stack = stack[:len(stack)-1]
+			i++
+			if valueDone(i) {
+				return data[:i], i, true
+			}
+		case c == ':' && state == expectColon:
+			stack[len(stack)-1].state = expectValue
+			i++
+		case c == ',' && state == expectComma:
+			if open == '{' {
+				stack[len(stack)-1].state = expectKey
+			} else {
+				stack[len(stack)-1].state = expectValue
+			}
+			i++
+		case wantValue:
+			end := i
+			for end < len(data) && strings.IndexByte(",]} \t\r\n", data[end]) < 0 {
+				end++
+			}
+			// A number or literal running into the end may have been cut short.
+			if end >= len(data) || !json.Valid(data[i:end]) {
+				goto done
+			}
+			i = end
+			if valueDone(i) {
+				return data[:i], i, true
+			}
+		default:
+			goto done
+		}
+	}
+
+done:
+	if cut < 0 {
+		return nil, 0, false
+	}
+	completed := append([]byte(nil), data[:cut]...)
+	for j := len(cutStack) - 1; j >= 0; j-- {
+		// Cuts are only made after an opening bracket or a complete value,
+		// so closing everything still open is enough.
+		if cutStack[j].open == '{' {
+			completed = append(completed, '}')
+		} else {
+			completed = append(completed, ']')
+		}
+	}
+	return completed, cut, true
+}
+
+// typeRepairer rewrites JSON so it decodes into a Go type, converting values
+// of the wrong type where that's unambiguous and dropping them otherwise.
+type typeRepairer struct {
+	changes []string
+}
+
+func (r *typeRepairer) note(path, format string, args ...any) {
+	if path == "" {
+		path = "save"
+	}
+	r.changes = append(r.changes, path+": "+fmt.Sprintf(format, args...))
+}
+
+// value returns data fixed up to decode into t, or false if it should be
+// dropped.
+func (r *typeRepairer) value(data json.RawMessage, t reflect.Type, path string) (json.RawMessage, bool) {
+	trimmed := bytes.TrimSpace(data)
+	if bytes.Equal(trimmed, []byte("null")) {
+		return trimmed, true
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	var v any
+	if err := json.Unmarshal(trimmed, &v); err != nil {
+		r.note(path, "dropped unreadable value")
+		return nil, false
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if _, ok := v.(map[string]any); !ok {
+			r.note(path, "dropped %s where an object was expected", describeJSON(v))
+			return nil, false
+		}
+		return r.object(trimmed, t, path), true
+
+	case reflect.Slice:
+		if _, ok := v.([]any); !ok {
+			r.note(path, "dropped %s where a list was expected", describeJSON(v))
+			return nil, false
+		}
+		return r.array(trimmed, t.Elem(), path), true
+
+	case reflect.String:
+		switch v := v.(type) {
+		case string:
+			return trimmed, true
+		case float64, bool:
+			fixed, _ := json.Marshal(string(trimmed))
+			r.note(path, "converted %s to text", describeJSON(v))
+			return fixed, true
+		}
+
+	case reflect.Bool:
+		switch v := v.(type) {
+		case bool:
+			return trimmed, true
+		case float64:
+			if v == 0 || v == 1 {
+				r.note(path, "converted %s to true/false", describeJSON(v))
+				return json.RawMessage(strconv.FormatBool(v == 1)), true
+			}
+		case string:
+			if b, err := strconv.ParseBool(v); err == nil {
+				r.note(path, "converted %s to true/false", describeJSON(v))
+				return json.RawMessage(strconv.FormatBool(b)), true
+			}
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var f float64
+		switch v := v.(type) {
+		case float64:
+			f = v
+			if _, err := strconv.ParseInt(string(trimmed), 10, 64); err == nil {
+				return trimmed, true
+			}
+		case string:
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				r.note(path, "dropped %s where a number was expected", describeJSON(v))
+				return nil, false
+			}
+			f = parsed
+		case bool:
+			if v {
+				f = 1
+			}
+		default:
+			r.note(path, "dropped %s where a number was expected", describeJSON(v))
+			return nil, false
+		}
+		if !math.IsInf(f, 0) && !math.IsNaN(f) && math.Abs(f) < 1<<53 {
+			fixed := json.RawMessage(strconv.FormatInt(int64(math.Round(f)), 10))
+			r.note(path, "converted %s to %s", trimmed, fixed)
+			return fixed, true
+		}
+
+	case reflect.Float32, reflect.Float64:
+		switch v := v.(type) {
+		case float64:
+			return trimmed, true
+		case string:
+			if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+				r.note(path, "converted %s to a number", describeJSON(v))
+				return json.RawMessage(strconv.FormatFloat(f, 'g', -1, 64)), true
+			}
+		}
+
+	default:
+		return trimmed, true
+	}
+
+	r.note(path, "dropped %s, which is the wrong type", describeJSON(v))
+	return nil, false
+}
+
+// object fixes the fields of a JSON object t knows about, keeping their
+// order and any fields it doesn't.
+func (r *typeRepairer) object(data json.RawMessage, t reflect.Type, path string) json.RawMessage {
+	fields, ok := objectFields(data)
+	if !ok {
+		return data
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for _, field := range fields {
+		value := field.value
+		if fieldType, known := jsonFieldType(t, field.key); known {
+			fixed, keep := r.value(field.value, fieldType, joinPath(path, field.key))
+			if !keep {
+				continue
+			}
+			value = fixed
+		}
+
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		key, _ := Marshal(field.key)
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes()
+}
+
+// array fixes each element of a JSON array. Elements that can't be fixed
+// become the element type's zero value so later elements keep their index.
+func (r *typeRepairer) array(data json.RawMessage, elem reflect.Type, path string) json.RawMessage {
+	var elements []json.RawMessage
+	if err := json.Unmarshal(data, &elements); err != nil {
+		return data
+	}
+
+	for i, element := range elements {
+		fixed, keep := r.value(element, elem, fmt.Sprintf("%s[%d]", path, i))
+		if !keep {
+			fixed, _ = Marshal(reflect.Zero(elem).Interface())
+		}
+		elements[i] = fixed
+	}
+
+	fixed, err := Marshal(elements)
+	if err != nil {
+		return data
+	}
+	return fixed
+}
+
+// jsonFieldType finds the type of t's field for key the way encoding/json
+// matches them: exact name first, then ignoring case.
+func jsonFieldType(t reflect.Type, key string) (reflect.Type, bool) {
+	var folded reflect.Type
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := field.Name
+		if tag, ok := field.Tag.Lookup("json"); ok {
+			if tag == "-" {
+				continue
+			}
+			if tagName, _, _ := strings.Cut(tag, ","); tagName != "" {
+				name = tagName
+			}
+		}
+		if name == key {
+			return field.Type, true
+		}
+		if folded == nil && strings.EqualFold(name, key) {
+			folded = field.Type
+		}
+	}
+	return folded, folded != nil
+}
+
+// hasKnownField reports whether data is an object with at least one of t's
+// fields.
+func hasKnownField(data json.RawMessage, t reflect.Type) bool {
+	fields, _ := objectFields(data)
+	for _, field := range fields {
+		if _, known := jsonFieldType(t, field.key); known {
+			return true
+		}
+	}
+	return false
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func describeJSON(v any) string {
+	switch v := v.(type) {
+	case string:
+		return strconv.Quote(v)
+	case float64, bool:
+		return fmt.Sprint(v)
+	case []any:
+		return "a list"
+	case map[string]any:
+		return "an object"
+	}
+	return "null"
+}
+
+// RepairedPath returns a file name next to path, which doesn't exist yet, to
+// write a repaired copy of the save at path to.
+func RepairedPath(path string) string {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext) + "_repaired"
+	candidate := base + ext
+	for n := 2; ; n++ {
+		if _, err := os.Stat(candidate); err != nil {
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s_%d%s", base, n, ext)
+	}
+}
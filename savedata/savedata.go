@@ -0,0 +1,191 @@
+// Package savedata reads, edits and writes Elestrals Awakened saves and the
+// Pandora's Bank bank file. It has no GUI dependencies so scripts and other
+// frontends can share the app's logic.
+package savedata
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type CombatPos struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+	Z float64 `json:"z"`
+
+	raw json.RawMessage
+}
+
+type StatStages struct {
+	PhysicalAttack  int `json:"physicalAttack"`
+	AstralAttack    int `json:"astralAttack"`
+	PhysicalDefense int `json:"physicalDefense"`
+	AstralDefense   int `json:"astralDefense"`
+	Speed           int `json:"speed"`
+	Accuracy        int `json:"accuracy"`
+	Evasion         int `json:"evasion"`
+
+	raw json.RawMessage
+}
+
+type ElestralID struct {
+	SerializedVersion string `json:"serializedVersion"`
+	Hash              string `json:"Hash"`
+
+	raw json.RawMessage
+}
+
+type Elestral struct {
+	ID                          ElestralID `json:"id"`
+	Name                        string     `json:"name"`
+	Species                     string     `json:"species"`
+	UsesStellarMaterial         bool       `json:"usesStellarMaterial"`
+	Element                     int        `json:"element"`
+	SubElement                  int        `json:"subElement"`
+	HealthBaseStat              int        `json:"healthBaseStat"`
+	PhysicalAttack              int        `json:"physicalAttack"`
+	SpecialAttack               int        `json:"specialAttack"`
+	PhysicalDefense             int        `json:"physicalDefense"`
+	SpecialDefense              int        `json:"specialDefense"`
+	Speed                       int        `json:"speed"`
+	Ability0Name                string     `json:"Ability0Name"`
+	Ability1Name                string     `json:"Ability1Name"`
+	Ability2Name                string     `json:"Ability2Name"`
+	Ability3Name                string     `json:"Ability3Name"`
+	EmpoweredAbilityName        string     `json:"empoweredAbilityName"`
+	CurrentLevel                int        `json:"currentLevel"`
+	Health                      int        `json:"health"`
+	MaxHealth                   int        `json:"maxHealth"`
+	IsActiveCombat              bool       `json:"isActiveInCombat"`
+	IsDodgeEnabled              bool       `json:"isDodgeEnabled"`
+	IsCaster                    bool       `json:"isCaster"`
+	IsStellar                   bool       `json:"isStellar"`
+	TeamSlot                    int        `json:"teamSlot"`
+	AbilityHitIndex             int        `json:"abilityHitIndex"`
+	BondMeter                   int        `json:"bondMeter"`
+	TurnOrderUIIndex            int        `json:"turnOrderUIIndex"`
+	SelectedAbilityIndex        int        `json:"selectedAbilityIndex"`
+	ShouldSkipTurn              bool       `json:"shouldSkipTurn"`
+	LastDodgeTime               float64    `json:"lastDodgeTime"`
+	LastSuccessfulDodgeTime     float64    `json:"lastSuccessfulDodgeTime"`
+	CombatPos                   CombatPos  `json:"CombatPos"`
+	CharacterType               int        `json:"characterType"`
+	StatStages                  StatStages `json:"statStages"`
+	SuppressedSlots             int        `json:"suppressedSlots"`
+	SlotsUsedThisBattle         int        `json:"slotsUsedThisBattle"`
+	IncomingDamageMultiplier    float64    `json:"IncomingDamageMultiplier"`
+	OutgoingDamageMultiplier    float64    `json:"OutgoingDamageMultiplier"`
+	MovesPerformedSinceLastSwap int        `json:"MovesPerformedSinceLastSwap"`
+	LastUsedAbilitySlot         int        `json:"lastUsedAbilitySlot"`
+	HasUsedEmpoweredAbility     bool       `json:"hasUsedEmpoweredAbility"`
+
+	raw json.RawMessage
+}
+
+type PlayerData struct {
+	Name            string    `json:"Name"`
+	SpiritElement   int       `json:"SpiritElement"`
+	IsMaleCharacter bool      `json:"isMaleCharacter"`
+	Money           int       `json:"Money"`
+	FocusedSlot     int       `json:"FocusedSlot"`
+	Character0      *Elestral `json:"Character0"`
+	Character1      *Elestral `json:"Character1"`
+	Character2      *Elestral `json:"Character2"`
+	Character3      *Elestral `json:"Character3"`
+	MaxSp           int       `json:"MaxCasterSP"`
+	CurrentSp       int       `json:"CasterSP"`
+	BondMeter       int       `json:"BondMeter"`
+
+	raw json.RawMessage
+}
+
+type StorageEntry struct {
+	CharacterData *Elestral `json:"CharacterData"`
+
+	raw json.RawMessage
+}
+
+type StorageBox struct {
+	Entries []StorageEntry `json:"entries"`
+
+	raw json.RawMessage
+}
+
+type ActiveBoons struct {
+	ActiveBoonNames []string `json:"ActiveBoonNames"`
+	BoonUsageCounts []int    `json:"BoonUsageCounts"`
+
+	raw json.RawMessage
+}
+
+type GameSave struct {
+	ActivePlayerData PlayerData   `json:"activePlayerData"`
+	StorageBoxes     []StorageBox `json:"storageBoxes"`
+	GameFlags        []string     `json:"gameFlags"`
+	ActiveBoons      ActiveBoons  `json:"activeBoons"`
+	CurrentSceneName string       `json:"currentSceneName"`
+	SaveVersion      string       `json:"saveVersion"`
+	SaveTimestamp    string       `json:"saveTimestamp"`
+
+	raw    json.RawMessage
+	layout saveLayout
+}
+
+type Bank struct {
+	SaveVersion string      `json:"saveVersion,omitempty"`
+	Elestrals   []*Elestral `json:"elestrals"`
+}
+
+// Empty reports whether e holds no Elestral. The game keeps cleared slots as
+// zero valued Elestrals rather than null, so both count.
+func (e *Elestral) Empty() bool {
+	return e == nil || e.Species == ""
+}
+
+// Party returns the four party slots in order. Slots may be nil or Empty.
+func (p *PlayerData) Party() []*Elestral {
+	return []*Elestral{p.Character0, p.Character1, p.Character2, p.Character3}
+}
+
+// Elestrals returns the party slots followed by every storage slot, empty or
+// not.
+func (gameSave *GameSave) Elestrals() []*Elestral {
+	elestrals := gameSave.ActivePlayerData.Party()
+	for _, box := range gameSave.StorageBoxes {
+		for _, entry := range box.Entries {
+			elestrals = append(elestrals, entry.CharacterData)
+		}
+	}
+	return elestrals
+}
+
+// PartySpecies lists the species in the party, skipping empty slots.
+func (gameSave *GameSave) PartySpecies() []string {
+	var species []string
+	for _, e := range gameSave.ActivePlayerData.Party() {
+		if !e.Empty() {
+			species = append(species, e.Species)
+		}
+	}
+	return species
+}
+
+// elementNames are the element codes the game is known to use.
+var elementNames = map[int]string{
+	0: "N/A",
+	1: "Earth",
+	2: "Fire",
+	3: "Water",
+	4: "Thunder",
+	5: "Wind",
+	6: "Frost",
+	7: "Solar",
+	8: "Lunar",
+}
+
+func ElementName(element int) string {
+	if name, ok := elementNames[element]; ok {
+		return name
+	}
+	return fmt.Sprintf("Unknown (%d)", element)
+}
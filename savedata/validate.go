@@ -0,0 +1,293 @@
+package savedata
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type Severity int
+
+const (
+	SeverityInfo Severity = iota
+	SeverityWarning
+	SeverityError
+)
+
+func (severity Severity) String() string {
+	switch severity {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	default:
+		return "info"
+	}
+}
+
+func (severity Severity) MarshalText() ([]byte, error) {
+	return []byte(severity.String()), nil
+}
+
+// Finding is one odd state Validate found. Location is where it is, in the
+// same words the rest of the app uses ("Party slot 1", "Storage Box 2 slot
+// 3", "Bank 4" or "Player"), and Subject the Elestral it is about, if any.
+type Finding struct {
+	Severity Severity `json:"severity"`
+	Location string   `json:"location"`
+	Subject  string   `json:"subject,omitempty"`
+	Message  string   `json:"message"`
+	// FixDescription says what Fix does; it is empty when there is no fix
+	// that is safe to make without asking.
+	FixDescription string `json:"fix,omitempty"`
+
+	fix func()
+}
+
+func (finding Finding) String() string {
+	where := finding.Location
+	if finding.Subject != "" {
+		where += " " + finding.Subject
+	}
+	return fmt.Sprintf("%s: %s: %s", finding.Severity, where, finding.Message)
+}
+
+func (finding Finding) CanFix() bool {
+	return finding.fix != nil
+}
+
+// Fix applies the finding's fix to the save or bank it was found in. The
+// save and bank must not have been changed since they were validated.
+func (finding Finding) Fix() {
+	if finding.fix != nil {
+		finding.fix()
+	}
+}
+
+// Validate looks for states the game shouldn't produce in gameSave and, if
+// it isn't nil, bank. Findings are ordered most severe first.
+func Validate(gameSave *GameSave, bank *Bank) []Finding {
+	var findings []Finding
+	add := func(finding Finding) {
+		findings = append(findings, finding)
+	}
+
+	player := &gameSave.ActivePlayerData
+	if _, ok := elementNames[player.SpiritElement]; !ok {
+		add(Finding{
+			Severity: SeverityWarning,
+			Location: "Player",
+			Message:  fmt.Sprintf("spirit element %d is not a known element", player.SpiritElement),
+		})
+	}
+
+	var nullSlots, placeholderSlots []string
+	for i, e := range player.Party() {
+		location := AtParty(i).String()
+		switch {
+		case e == nil:
+			nullSlots = append(nullSlots, location)
+			continue
+		case e.Empty():
+			placeholderSlots = append(placeholderSlots, location)
+		}
+
+		validateElestral(e, location, add)
+		if !e.Empty() && e.TeamSlot != i {
+			add(Finding{
+				Severity:       SeverityWarning,
+				Location:       location,
+				Subject:        elestralLabel(e),
+				Message:        fmt.Sprintf("team slot is %d but it is in party slot %d", e.TeamSlot, i+1),
+				FixDescription: fmt.Sprintf("Set team slot to %d", i),
+				fix:            func() { e.TeamSlot = i },
+			})
+		}
+	}
+	if len(nullSlots) > 0 && len(placeholderSlots) > 0 {
+		add(Finding{
+			Severity: SeverityInfo,
+			Location: strings.Join(placeholderSlots, ", "),
+			Message:  fmt.Sprintf("empty slots are stored as blank Elestrals while %s %s null", strings.Join(nullSlots, ", "), isOrAre(len(nullSlots))),
+		})
+	}
+
+	for boxIdx, box := range gameSave.StorageBoxes {
+		for entryIdx, entry := range box.Entries {
+			if entry.CharacterData != nil {
+				validateElestral(entry.CharacterData, StorageSlot{Box: boxIdx, Entry: entryIdx}.String(), add)
+			}
+		}
+	}
+
+	if bank != nil {
+		for i, e := range bank.Elestrals {
+			location := AtBank(i).String()
+			if e.Empty() {
+				add(Finding{
+					Severity:       SeverityWarning,
+					Location:       location,
+					Message:        "the bank holds an empty Elestral",
+					FixDescription: "Remove it from the bank",
+					// By identity rather than index, so the fixes for
+					// several empty entries can all be applied.
+					fix: func() {
+						for j, other := range bank.Elestrals {
+							if other == e {
+								bank.Elestrals = append(bank.Elestrals[:j], bank.Elestrals[j+1:]...)
+								return
+							}
+						}
+					},
+				})
+				continue
+			}
+			validateElestral(e, location, add)
+		}
+	}
+
+	for _, finding := range duplicateHashes(gameSave, bank) {
+		add(finding)
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Severity > findings[j].Severity
+	})
+	return findings
+}
+
+// validateElestral checks the values of one Elestral, wherever it is kept.
+func validateElestral(e *Elestral, location string, add func(Finding)) {
+	if e.Empty() {
+		if e.Name != "" || e.ID.Hash != "" || e.CurrentLevel != 0 {
+			add(Finding{
+				Severity:       SeverityWarning,
+				Location:       location,
+				Message:        fmt.Sprintf("empty slot still holds data (name %q, hash %q)", e.Name, e.ID.Hash),
+				FixDescription: "Clear the slot",
+				fix:            func() { *e = Elestral{} },
+			})
+		}
+		return
+	}
+
+	subject := elestralLabel(e)
+	if e.Health > e.MaxHealth && e.MaxHealth >= 0 {
+		add(Finding{
+			Severity:       SeverityWarning,
+			Location:       location,
+			Subject:        subject,
+			Message:        fmt.Sprintf("health %d is above max health %d", e.Health, e.MaxHealth),
+			FixDescription: fmt.Sprintf("Set health to %d", e.MaxHealth),
+			fix:            func() { e.Health = e.MaxHealth },
+		})
+	}
+	if e.Health < 0 {
+		add(Finding{
+			Severity:       SeverityWarning,
+			Location:       location,
+			Subject:        subject,
+			Message:        fmt.Sprintf("health %d is negative", e.Health),
+			FixDescription: "Set health to 0",
+			fix:            func() { e.Health = 0 },
+		})
+	}
+	if e.IsActiveCombat {
+		add(Finding{
+			Severity:       SeverityWarning,
+			Location:       location,
+			Subject:        subject,
+			Message:        "is still marked as active in combat",
+			FixDescription: "Clear the active in combat flag",
+			fix:            func() { e.IsActiveCombat = false },
+		})
+	}
+	if _, ok := elementNames[e.Element]; !ok {
+		add(Finding{
+			Severity: SeverityWarning,
+			Location: location,
+			Subject:  subject,
+			Message:  fmt.Sprintf("element %d is not a known element", e.Element),
+		})
+	}
+	if _, ok := elementNames[e.SubElement]; !ok {
+		add(Finding{
+			Severity: SeverityWarning,
+			Location: location,
+			Subject:  subject,
+			Message:  fmt.Sprintf("sub element %d is not a known element", e.SubElement),
+		})
+	}
+	if e.ID.Hash == "" {
+		add(Finding{
+			Severity: SeverityWarning,
+			Location: location,
+			Subject:  subject,
+			Message:  "has no ID hash",
+		})
+	}
+}
+
+// duplicateHashes finds Elestrals that share an ID.Hash, which the game
+// expects to be unique. Which copy to keep is the user's call, so there is no
+// fix.
+func duplicateHashes(gameSave *GameSave, bank *Bank) []Finding {
+	locations := map[string][]string{}
+	subjects := map[string]string{}
+	var order []string
+	add := func(e *Elestral, location string) {
+		if e.Empty() || e.ID.Hash == "" {
+			return
+		}
+		if _, seen := locations[e.ID.Hash]; !seen {
+			order = append(order, e.ID.Hash)
+			subjects[e.ID.Hash] = elestralLabel(e)
+		}
+		locations[e.ID.Hash] = append(locations[e.ID.Hash], location)
+	}
+
+	Each(gameSave, bank, func(l Location, e *Elestral) {
+		add(e, l.String())
+	})
+
+	var findings []Finding
+	for _, hash := range order {
+		if len(locations[hash]) < 2 {
+			continue
+		}
+		findings = append(findings, Finding{
+			Severity: SeverityError,
+			Location: strings.Join(locations[hash], ", "),
+			Subject:  subjects[hash],
+			Message:  fmt.Sprintf("%d Elestrals share the hash %s", len(locations[hash]), hash),
+		})
+	}
+	return findings
+}
+
+func isOrAre(n int) string {
+	if n == 1 {
+		return "is"
+	}
+	return "are"
+}
+
+// Fixable returns the findings that have a fix.
+func Fixable(findings []Finding) []Finding {
+	var fixable []Finding
+	for _, finding := range findings {
+		if finding.CanFix() {
+			fixable = append(fixable, finding)
+		}
+	}
+	return fixable
+}
+
+// FixAll applies every fix Validate offers and returns the fixes made.
+func FixAll(gameSave *GameSave, bank *Bank) []Finding {
+	fixable := Fixable(Validate(gameSave, bank))
+	for _, finding := range fixable {
+		finding.Fix()
+	}
+	return fixable
+}
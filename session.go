@@ -0,0 +1,661 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"pandorasbank/activity"
+	"pandorasbank/atomicfile"
+	"pandorasbank/backup"
+	"pandorasbank/journal"
+	"pandorasbank/savedata"
+)
+
+// maxHistoryEntries caps the undo history, including the state the save was
+// opened in.
+const maxHistoryEntries = 50
+
+// historyEntry is the state of the save and bank after an edit.
+type historyEntry struct {
+	Action string          `json:"action"`
+	Time   time.Time       `json:"time"`
+	Save   json.RawMessage `json:"save"`
+	Bank   json.RawMessage `json:"bank"`
+}
+
+// editHistory is the undo stack of one open save. Entries before Position
+// can be undone to, entries after it redone to.
+type editHistory struct {
+	SavePath string         `json:"savePath"`
+	Entries  []historyEntry `json:"entries"`
+	Position int            `json:"position"`
+}
+
+func getHistoryFilePath() (string, error) {
+	exePath, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	exeDir := filepath.Dir(exePath)
+	return filepath.Join(exeDir, "pbank_history.json.gz"), nil
+}
+
+func loadEditHistory() (*editHistory, error) {
+	historyPath, err := getHistoryFilePath()
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(historyPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	var history editHistory
+	if err := json.NewDecoder(reader).Decode(&history); err != nil {
+		return nil, err
+	}
+	if history.Position < 0 || history.Position >= len(history.Entries) {
+		return nil, fmt.Errorf("history position %d is out of range", history.Position)
+	}
+	return &history, nil
+}
+
+func saveEditHistory(history *editHistory) error {
+	historyPath, err := getHistoryFilePath()
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(writer).Encode(history); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	return atomicfile.WriteFile(historyPath, buf.Bytes(), 0644, func(data []byte) error {
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(io.Discard, reader)
+		return err
+	})
+}
+
+func deleteEditHistory() error {
+	historyPath, err := getHistoryFilePath()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(historyPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// editMode is how a session writes its edits.
+type editMode int
+
+const (
+	// editAutosave writes every edit as soon as it is made.
+	editAutosave editMode = iota
+	// editStaged keeps edits in memory until they are applied.
+	editStaged
+	// editSandbox is editStaged on a copy nobody meant to write back, so
+	// applying asks first.
+	editSandbox
+)
+
+// saveSession is an open save and the bank alongside it. Every edit goes
+// through Commit, which records it for undo and, unless edits are staged,
+// writes what changed.
+type saveSession struct {
+	Path     string
+	GameSave *savedata.GameSave
+	Bank     *savedata.Bank
+	History  *editHistory
+	Mode     editMode
+
+	// Locked refuses every write while the game is running.
+	Locked bool
+	// Unwritable is why the save or bank on disk can't be written, if its
+	// version is newer than Pandora's Bank supports. Every write is refused
+	// while it is set.
+	Unwritable error
+
+	// OnChanged runs after the save or bank changed, to redraw them.
+	OnChanged func()
+
+	// disk is the save and bank as they are on disk, pending the staged
+	// edits since they were last applied.
+	disk    historyEntry
+	pending []string
+
+	backups  *backup.Manager
+	journal  *journal.Journal
+	activity *activity.Log
+	window   fyne.Window
+	backedUp bool
+}
+
+func marshalSessionState(gameSave *savedata.GameSave, bank *savedata.Bank) (json.RawMessage, json.RawMessage, error) {
+	saveData, err := savedata.Marshal(gameSave)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error marshaling save: %w", err)
+	}
+	bankData, err := savedata.Marshal(bank)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error marshaling bank: %w", err)
+	}
+	return saveData, bankData, nil
+}
+
+// newSaveSession picks up the history left from a previous run if it is for
+// this save and the save and bank haven't changed since, otherwise it starts
+// a new one. Edits that were staged but never applied are not kept.
+func newSaveSession(path string, mode editMode, gameSave *savedata.GameSave, bank *savedata.Bank, backups *backup.Manager, transfers *journal.Journal, activityLog *activity.Log, window fyne.Window) (*saveSession, error) {
+	saveData, bankData, err := marshalSessionState(gameSave, bank)
+	if err != nil {
+		return nil, err
+	}
+
+	opened := historyEntry{Action: "Open save", Time: time.Now(), Save: saveData, Bank: bankData}
+	session := &saveSession{
+		Path:     path,
+		GameSave: gameSave,
+		Bank:     bank,
+		Mode:     mode,
+		disk:     opened,
+		backups:  backups,
+		journal:  transfers,
+		activity: activityLog,
+		window:   window,
+	}
+
+	if history, err := loadEditHistory(); err == nil && history.SavePath == path {
+		// Staged edits leave the position ahead of what is on disk, so look
+		// back from it for the entry that matches.
+		for position := history.Position; position >= 0; position-- {
+			if opened.sameState(history.Entries[position]) {
+				history.Position = position
+				session.History = history
+				return session, nil
+			}
+		}
+	}
+
+	session.History = &editHistory{
+		SavePath: path,
+		Entries:  []historyEntry{opened},
+	}
+	if err := saveEditHistory(session.History); err != nil {
+		return nil, fmt.Errorf("error saving edit history: %w", err)
+	}
+	return session, nil
+}
+
+func (entry historyEntry) sameState(other historyEntry) bool {
+	return bytes.Equal(entry.Save, other.Save) && bytes.Equal(entry.Bank, other.Bank)
+}
+
+func (session *saveSession) current() historyEntry {
+	return session.History.Entries[session.History.Position]
+}
+
+func (session *saveSession) CanUndo() bool {
+	return session.History.Position > 0
+}
+
+func (session *saveSession) CanRedo() bool {
+	return session.History.Position < len(session.History.Entries)-1
+}
+
+func (session *saveSession) Staged() bool {
+	return session.Mode != editAutosave
+}
+
+// HasPending reports whether there are staged edits that aren't on disk.
+func (session *saveSession) HasPending() bool {
+	return !session.disk.sameState(session.current())
+}
+
+// PendingActions describes the staged edits, oldest first.
+func (session *saveSession) PendingActions() []string {
+	return session.pending
+}
+
+// PendingDiff compares what is on disk with the staged save and bank.
+func (session *saveSession) PendingDiff() (savedata.SaveDiff, error) {
+	diskSave, err := session.decodeSave(session.disk.Save)
+	if err != nil {
+		return savedata.SaveDiff{}, err
+	}
+	var diskBank savedata.Bank
+	if err := json.Unmarshal(session.disk.Bank, &diskBank); err != nil {
+		return savedata.SaveDiff{}, err
+	}
+
+	diff := savedata.Diff(diskSave, session.GameSave)
+	diff.Changes = append(diff.Changes, savedata.DiffBanks(&diskBank, session.Bank).Changes...)
+	return diff, nil
+}
+
+// stage records action as pending, or forgets the pending edits once they
+// have been undone back to what is on disk.
+func (session *saveSession) stage(action string) {
+	if session.HasPending() {
+		session.pending = append(session.pending, action)
+	} else {
+		session.pending = nil
+	}
+}
+
+// Apply writes the staged edits.
+func (session *saveSession) Apply() {
+	if !session.checkUnlocked() {
+		return
+	}
+	current := session.current()
+	action := "Apply " + strings.Join(session.pending, ", ")
+	if err := session.write(action, session.disk, current.Save, current.Bank); err != nil {
+		dialog.ShowError(fmt.Errorf("%w\n\nYour changes are still pending.", err), session.window)
+		return
+	}
+	session.disk = current
+	session.pending = nil
+	session.changed()
+}
+
+// Discard throws the staged edits away and goes back to what is on disk.
+// They can still be redone from the history.
+func (session *saveSession) Discard() {
+	if !session.HasPending() {
+		return
+	}
+	if err := session.load(session.disk); err != nil {
+		dialog.ShowError(err, session.window)
+		return
+	}
+
+	history := session.History
+	for position := history.Position; position >= 0; position-- {
+		if session.disk.sameState(history.Entries[position]) {
+			history.Position = position
+			break
+		}
+	}
+	if !session.disk.sameState(session.current()) {
+		// The entry on disk fell off the end of the history.
+		history.Entries = append(history.Entries[:history.Position+1], session.disk)
+		history.Position = len(history.Entries) - 1
+	}
+	session.pending = nil
+
+	if err := saveEditHistory(history); err != nil {
+		dialog.ShowError(fmt.Errorf("error saving edit history: %w", err), session.window)
+	}
+	session.changed()
+}
+
+// write saves whichever of the save and bank differ from base, the entry on
+// disk, as one journaled transaction so a transfer between them can't leave
+// an Elestral in both or in neither.
+func (session *saveSession) write(action string, base historyEntry, saveData, bankData json.RawMessage) error {
+	var writes []journal.Write
+
+	if !bytes.Equal(saveData, base.Save) {
+		data, err := savedata.EncodeSave(session.GameSave)
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(session.Path); err == nil {
+			reason := backup.ReasonEdit
+			if !session.backedUp {
+				reason = backup.ReasonSession
+			}
+			if _, err := session.backups.Snapshot(session.Path, reason); err != nil {
+				return fmt.Errorf("error backing up save: %w", err)
+			}
+			session.backedUp = true
+		}
+		writes = append(writes, journal.Write{Path: session.Path, Data: data, Verify: savedata.VerifySave})
+	}
+
+	if !bytes.Equal(bankData, base.Bank) {
+		bankPath, err := getBankFilePath()
+		if err != nil {
+			return fmt.Errorf("error saving bank: %w", err)
+		}
+		data, err := savedata.EncodeBank(session.Bank)
+		if err != nil {
+			return fmt.Errorf("error saving bank: %w", err)
+		}
+		writes = append(writes, journal.Write{Path: bankPath, Data: data, Verify: savedata.VerifyBank})
+	}
+
+	if len(writes) == 0 {
+		return nil
+	}
+	if err := session.journal.Commit(action, writes); err != nil {
+		return err
+	}
+	session.logChanges(action, base)
+	return nil
+}
+
+// logChanges records in the activity log what changed from base to the save
+// and bank as they are now. The change is already written, so failing to log
+// it only warns.
+func (session *saveSession) logChanges(action string, base historyEntry) {
+	if session.activity == nil {
+		return
+	}
+	beforeSave, err := session.decodeSave(base.Save)
+	if err != nil {
+		beforeSave = nil
+	}
+	beforeBank := &savedata.Bank{}
+	if err := json.Unmarshal(base.Bank, beforeBank); err != nil {
+		beforeBank = nil
+	}
+
+	entries := activity.Changes(action, session.Path, beforeSave, session.GameSave, beforeBank, session.Bank)
+	if err := session.activity.Append(entries...); err != nil {
+		dialog.ShowError(fmt.Errorf("error writing activity log: %w", err), session.window)
+	}
+}
+
+func (session *saveSession) decodeSave(data json.RawMessage) (*savedata.GameSave, error) {
+	var gameSave savedata.GameSave
+	if err := json.Unmarshal(data, &gameSave); err != nil {
+		return nil, err
+	}
+	gameSave.UseLayoutOf(session.GameSave)
+	return &gameSave, nil
+}
+
+// load replaces the in-memory save and bank with entry. The pointers stay the
+// same so everything holding them sees the change.
+func (session *saveSession) load(entry historyEntry) error {
+	gameSave, err := session.decodeSave(entry.Save)
+	if err != nil {
+		return fmt.Errorf("error reading save from history: %w", err)
+	}
+	var bank savedata.Bank
+	if err := json.Unmarshal(entry.Bank, &bank); err != nil {
+		return fmt.Errorf("error reading bank from history: %w", err)
+	}
+
+	*session.GameSave = *gameSave
+	*session.Bank = bank
+	return nil
+}
+
+// ReadOnly reports whether edits are refused, because the game is running or
+// the files are of a version that can't be written.
+func (session *saveSession) ReadOnly() bool {
+	return session.Locked || session.Unwritable != nil
+}
+
+// checkUnlocked tells the user why nothing can be changed while the game runs
+// or the files can't be written.
+func (session *saveSession) checkUnlocked() bool {
+	if session.Unwritable != nil {
+		dialog.ShowError(unwritableError(session.Unwritable), session.window)
+		return false
+	}
+	if session.Locked {
+		dialog.ShowInformation("Game Running",
+			"Elestrals Awakened is running and would overwrite your changes. Editing unlocks when the game exits.", session.window)
+	}
+	return !session.Locked
+}
+
+// unwritableError explains that data of a version that can't be written is
+// only shown.
+func unwritableError(err error) error {
+	return fmt.Errorf("%w\n\nThe save can be viewed but changes to it will not be written.", err)
+}
+
+func (session *saveSession) changed() {
+	if session.OnChanged != nil {
+		session.OnChanged()
+	}
+}
+
+// Commit records the edits made to the save and bank since the last commit in
+// the history as action and, unless edits are staged, writes them. If nothing
+// changed it does nothing. If writing fails the edits are dropped so what is
+// shown matches the files.
+func (session *saveSession) Commit(action string) {
+	history := session.History
+	saveData, bankData, err := marshalSessionState(session.GameSave, session.Bank)
+	if err == nil {
+		current := session.current()
+		if bytes.Equal(saveData, current.Save) && bytes.Equal(bankData, current.Bank) {
+			return
+		}
+		if !session.checkUnlocked() {
+			if loadErr := session.load(current); loadErr != nil {
+				dialog.ShowError(loadErr, session.window)
+			}
+			session.changed()
+			return
+		}
+		if !session.Staged() {
+			err = session.write(action, session.disk, saveData, bankData)
+		}
+	}
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("%w\n\nYour change was not saved.", err), session.window)
+		if loadErr := session.load(session.current()); loadErr != nil {
+			dialog.ShowError(loadErr, session.window)
+		}
+		session.changed()
+		return
+	}
+
+	entry := historyEntry{
+		Action: action,
+		Time:   time.Now(),
+		Save:   saveData,
+		Bank:   bankData,
+	}
+	history.Entries = append(history.Entries[:history.Position+1], entry)
+	if excess := len(history.Entries) - maxHistoryEntries; excess > 0 {
+		history.Entries = history.Entries[excess:]
+	}
+	history.Position = len(history.Entries) - 1
+	if session.Staged() {
+		session.stage(action)
+	} else {
+		session.disk = entry
+	}
+
+	if err := saveEditHistory(history); err != nil {
+		dialog.ShowError(fmt.Errorf("error saving edit history: %w", err), session.window)
+	}
+	session.changed()
+}
+
+// Reload takes on a save and bank that were changed on disk by something
+// else, dropping any pending edits. It is recorded in the history so the
+// reload itself can be undone.
+func (session *saveSession) Reload(gameSave *savedata.GameSave, bank *savedata.Bank) error {
+	saveData, bankData, err := marshalSessionState(gameSave, bank)
+	if err != nil {
+		return err
+	}
+
+	entry := historyEntry{
+		Action: "Reload changes made outside Pandora's Bank",
+		Time:   time.Now(),
+		Save:   saveData,
+		Bank:   bankData,
+	}
+	previous := session.disk
+	*session.GameSave = *gameSave
+	*session.Bank = *bank
+	session.logChanges(entry.Action, previous)
+	session.disk = entry
+	session.pending = nil
+
+	history := session.History
+	history.Entries = append(history.Entries[:history.Position+1], entry)
+	if excess := len(history.Entries) - maxHistoryEntries; excess > 0 {
+		history.Entries = history.Entries[excess:]
+	}
+	history.Position = len(history.Entries) - 1
+
+	if err := saveEditHistory(history); err != nil {
+		dialog.ShowError(fmt.Errorf("error saving edit history: %w", err), session.window)
+	}
+	session.changed()
+	return nil
+}
+
+// KeepPending keeps the staged edits after the save or bank changed on disk.
+// Applying them later writes them over the change.
+func (session *saveSession) KeepPending(gameSave *savedata.GameSave, bank *savedata.Bank) error {
+	saveData, bankData, err := marshalSessionState(gameSave, bank)
+	if err != nil {
+		return err
+	}
+	session.disk = historyEntry{Action: "Changed outside Pandora's Bank", Time: time.Now(), Save: saveData, Bank: bankData}
+	session.changed()
+	return nil
+}
+
+// GoTo rewinds or replays the save and bank to history entry position.
+func (session *saveSession) GoTo(position int) {
+	history := session.History
+	if position < 0 || position >= len(history.Entries) || position == history.Position {
+		return
+	}
+	if !session.checkUnlocked() {
+		return
+	}
+
+	previous := session.current()
+	entry := history.Entries[position]
+	if err := session.load(entry); err != nil {
+		dialog.ShowError(err, session.window)
+		return
+	}
+	if !session.Staged() {
+		if err := session.write("Return to "+entry.Action, session.disk, entry.Save, entry.Bank); err != nil {
+			dialog.ShowError(err, session.window)
+			if loadErr := session.load(previous); loadErr != nil {
+				dialog.ShowError(loadErr, session.window)
+			}
+			session.changed()
+			return
+		}
+		session.disk = entry
+	}
+
+	var action string
+	switch position {
+	case history.Position - 1:
+		action = "Undo " + previous.Action
+	case history.Position + 1:
+		action = "Redo " + entry.Action
+	default:
+		action = "Jump to " + entry.Action
+	}
+	history.Position = position
+	if session.Staged() {
+		session.stage(action)
+	}
+	if err := saveEditHistory(history); err != nil {
+		dialog.ShowError(fmt.Errorf("error saving edit history: %w", err), session.window)
+	}
+	session.changed()
+}
+
+func (session *saveSession) Undo() {
+	if session.CanUndo() {
+		session.GoTo(session.History.Position - 1)
+	}
+}
+
+func (session *saveSession) Redo() {
+	if session.CanRedo() {
+		session.GoTo(session.History.Position + 1)
+	}
+}
+
+// showEditHistory lists the edits to the open save. Selecting one undoes or
+// redoes everything up to it.
+func showEditHistory(bankWindow *BankWindow) {
+	if bankWindow.HistoryList != nil {
+		return
+	}
+
+	historyWindow := fyne.CurrentApp().NewWindow("Edit History")
+
+	entries := func() []historyEntry {
+		if bankWindow.Session == nil {
+			return nil
+		}
+		return bankWindow.Session.History.Entries
+	}
+
+	var list *widget.List
+	list = widget.NewList(
+		func() int { return len(entries()) },
+		func() fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(id widget.ListItemID, object fyne.CanvasObject) {
+			entry := entries()[id]
+			label := object.(*widget.Label)
+			text := fmt.Sprintf("%s  %s", entry.Time.Local().Format("15:04:05"), entry.Action)
+			current := bankWindow.Session.History.Position == id
+			if current {
+				text += "  (current)"
+			}
+			label.SetText(text)
+			label.TextStyle = fyne.TextStyle{Bold: current}
+			label.Refresh()
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) {
+		list.UnselectAll()
+		if bankWindow.Session != nil {
+			bankWindow.Session.GoTo(id)
+		}
+	}
+
+	bankWindow.HistoryList = list
+	historyWindow.SetOnClosed(func() {
+		bankWindow.HistoryList = nil
+	})
+
+	historyWindow.SetContent(list)
+	historyWindow.Resize(fyne.NewSize(400, 500))
+	historyWindow.Show()
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// maxPendingLines is how many staged edits the pending panel lists before
+// summarising the rest.
+const maxPendingLines = 5
+
+// refreshPendingPanel shows the staged edits of the open save above the tabs,
+// with buttons to apply or discard them. It is empty when edits autosave.
+func refreshPendingPanel(bankWindow *BankWindow) {
+	panel := bankWindow.PendingPanel
+	panel.RemoveAll()
+
+	session := bankWindow.Session
+	if session == nil || !session.Staged() {
+		panel.Refresh()
+		return
+	}
+
+	header := "Staged editing: changes are not written until you apply them."
+	if session.Mode == editSandbox {
+		header = fmt.Sprintf("Sandbox: changes are never written to %s unless you apply them.", session.Path)
+	}
+	headerLabel := widget.NewLabel(header)
+	headerLabel.TextStyle = fyne.TextStyle{Bold: true}
+	headerLabel.Wrapping = fyne.TextWrapWord
+	panel.Add(headerLabel)
+
+	actions := session.PendingActions()
+	summary := "No pending changes."
+	if session.HasPending() {
+		lines := actions
+		if len(lines) > maxPendingLines {
+			lines = append([]string{fmt.Sprintf("... and %d earlier changes", len(lines)-maxPendingLines)}, lines[len(lines)-maxPendingLines:]...)
+		}
+		summary = fmt.Sprintf("%d pending changes:\n- %s", len(actions), strings.Join(lines, "\n- "))
+	}
+	summaryLabel := widget.NewLabel(summary)
+	summaryLabel.Wrapping = fyne.TextWrapWord
+	panel.Add(summaryLabel)
+
+	reviewButton := widget.NewButton("Review Changes...", func() {
+		diff, err := session.PendingDiff()
+		if err != nil {
+			dialog.ShowError(err, bankWindow.Window)
+			return
+		}
+		showSaveDiff("Pending Changes", diff)
+	})
+	applyButton := widget.NewButton("Apply", func() {
+		applyPending(bankWindow)
+	})
+	applyButton.Importance = widget.HighImportance
+	discardButton := widget.NewButton("Discard", func() {
+		confirmDiscardPending(bankWindow, nil)
+	})
+	if !session.HasPending() {
+		reviewButton.Disable()
+		applyButton.Disable()
+		discardButton.Disable()
+	}
+	panel.Add(container.NewHBox(reviewButton, applyButton, discardButton))
+	panel.Add(widget.NewSeparator())
+	panel.Refresh()
+}
+
+// applyPending writes the staged edits, asking first for a sandbox.
+func applyPending(bankWindow *BankWindow) {
+	session := bankWindow.Session
+	if session == nil || !session.HasPending() {
+		return
+	}
+	if session.Mode != editSandbox {
+		session.Apply()
+		return
+	}
+
+	dialog.ShowConfirm("Write Sandbox Changes",
+		fmt.Sprintf("This sandbox is a copy of:\n%s\n\nWrite its %d pending changes to the real save and bank?", session.Path, len(session.PendingActions())),
+		func(confirm bool) {
+			if confirm {
+				session.Apply()
+			}
+		}, bankWindow.Window)
+}
+
+// confirmDiscardPending asks before throwing away staged edits, then runs
+// onDiscarded if set. With nothing pending it runs onDiscarded straight away.
+func confirmDiscardPending(bankWindow *BankWindow, onDiscarded func()) {
+	session := bankWindow.Session
+	if session == nil || !session.HasPending() {
+		if onDiscarded != nil {
+			onDiscarded()
+		}
+		return
+	}
+
+	dialog.ShowConfirm("Discard Changes",
+		fmt.Sprintf("Discard %d pending changes? Nothing has been written yet.", len(session.PendingActions())),
+		func(confirm bool) {
+			if !confirm {
+				return
+			}
+			session.Discard()
+			if onDiscarded != nil {
+				onDiscarded()
+			}
+		}, bankWindow.Window)
+}
+
+// setStagedEditing switches between autosaving and staging edits for the
+// open save and any opened later.
+func setStagedEditing(bankWindow *BankWindow, settings *Settings, enabled bool) {
+	session := bankWindow.Session
+	if !enabled && session != nil && session.Mode == editStaged && session.HasPending() {
+		dialog.ShowInformation("Staged Editing", "Apply or discard your pending changes before turning staged editing off.", bankWindow.Window)
+		return
+	}
+
+	settings.StagedEditing = enabled
+	if err := saveSettings(settings); err != nil {
+		dialog.ShowError(fmt.Errorf("error saving settings: %w", err), bankWindow.Window)
+	}
+
+	bankWindow.EditMode = defaultEditMode(settings)
+	if session != nil && session.Mode != editSandbox {
+		session.Mode = bankWindow.EditMode
+		refreshPendingPanel(bankWindow)
+	}
+}
+
+func defaultEditMode(settings *Settings) editMode {
+	if settings.StagedEditing {
+		return editStaged
+	}
+	return editAutosave
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"pandorasbank/savedata"
+)
+
+// storageSlotView is one entry of a storage box tab. The Elestral in it can
+// be dragged onto another entry of the same box, which moves it there or
+// swaps it with the one already there.
+type storageSlotView struct {
+	widget.BaseWidget
+
+	slot      savedata.StorageSlot
+	content   fyne.CanvasObject
+	highlight *canvas.Rectangle
+	// draggable is false for empty entries and while editing is locked.
+	draggable bool
+	// box is every entry view of the same box, the places it can be dropped.
+	box    *[]*storageSlotView
+	onDrop func(from, to savedata.StorageSlot)
+
+	dropAt   fyne.Position
+	dragging bool
+}
+
+func newStorageSlotView(slot savedata.StorageSlot, content fyne.CanvasObject, draggable bool, box *[]*storageSlotView, onDrop func(from, to savedata.StorageSlot)) *storageSlotView {
+	view := &storageSlotView{
+		slot:      slot,
+		content:   content,
+		highlight: canvas.NewRectangle(color.Transparent),
+		draggable: draggable,
+		box:       box,
+		onDrop:    onDrop,
+	}
+	view.ExtendBaseWidget(view)
+	return view
+}
+
+func (view *storageSlotView) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(container.NewStack(view.highlight, view.content))
+}
+
+func (view *storageSlotView) Dragged(event *fyne.DragEvent) {
+	if !view.draggable {
+		return
+	}
+	view.dragging = true
+	view.dropAt = event.AbsolutePosition
+	target := view.targetAt(view.dropAt)
+	for _, other := range *view.box {
+		other.setHighlighted(other == view || other == target)
+	}
+}
+
+func (view *storageSlotView) DragEnd() {
+	if !view.dragging {
+		return
+	}
+	view.dragging = false
+	for _, other := range *view.box {
+		other.setHighlighted(false)
+	}
+	if target := view.targetAt(view.dropAt); target != nil && target != view {
+		view.onDrop(view.slot, target.slot)
+	}
+}
+
+// targetAt returns the entry of the box under the window position at, or nil.
+func (view *storageSlotView) targetAt(at fyne.Position) *storageSlotView {
+	driver := fyne.CurrentApp().Driver()
+	for _, other := range *view.box {
+		if !other.Visible() {
+			continue
+		}
+		origin := driver.AbsolutePositionForObject(other)
+		size := other.Size()
+		if at.X >= origin.X && at.X < origin.X+size.Width && at.Y >= origin.Y && at.Y < origin.Y+size.Height {
+			return other
+		}
+	}
+	return nil
+}
+
+func (view *storageSlotView) setHighlighted(on bool) {
+	fill := color.Color(color.Transparent)
+	if on {
+		fill = theme.Color(theme.ColorNameSelection)
+	}
+	if view.highlight.FillColor != fill {
+		view.highlight.FillColor = fill
+		view.highlight.Refresh()
+	}
+}
+
+// moveInStorage moves the Elestral at from to to, swapping it with the one
+// there if the entry is taken.
+func moveInStorage(gameSave *savedata.GameSave, bank *savedata.Bank, from, to savedata.StorageSlot, onChange func(action string), myWindow fyne.Window) {
+	moving, err := savedata.Get(gameSave, bank, savedata.AtStorage(from))
+	if err != nil || moving == nil {
+		dialog.ShowError(fmt.Errorf("error reading %s: %w", from, err), myWindow)
+		return
+	}
+	movingName := moving.Name
+	other, err := savedata.Get(gameSave, bank, savedata.AtStorage(to))
+	if err != nil {
+		dialog.ShowError(err, myWindow)
+		return
+	}
+	action := fmt.Sprintf("Move %s to %s", movingName, to)
+	if other != nil {
+		action = fmt.Sprintf("Swap %s with %s", movingName, other.Name)
+	}
+
+	if err := savedata.Swap(gameSave, bank, savedata.AtStorage(from), savedata.AtStorage(to)); err != nil {
+		dialog.ShowError(err, myWindow)
+		return
+	}
+	if onChange != nil {
+		onChange(action)
+	}
+}
+
+// pickStorageEntry asks for the storage entry to move the Elestral at from
+// to, in any box. It is the keyboard way of dragging it there.
+func pickStorageEntry(gameSave *savedata.GameSave, boxes saveBoxes, from savedata.StorageSlot, title string, myWindow fyne.Window, onPicked func(to savedata.StorageSlot)) {
+	var boxOptions []string
+	for box := range gameSave.StorageBoxes {
+		boxOptions = append(boxOptions, fmt.Sprintf("%s (%d free)", boxes.Title(box), savedata.FreeSlots(gameSave, box)))
+	}
+
+	// entries are the entry indexes the entry picker lists, in order.
+	var entries []int
+	entrySelect := widget.NewSelect(nil, nil)
+	boxSelect := widget.NewSelect(boxOptions, nil)
+	boxSelect.OnChanged = func(string) {
+		box := boxSelect.SelectedIndex()
+		entries = nil
+		var options []string
+		for entryIdx, entry := range gameSave.StorageBoxes[box].Entries {
+			if box == from.Box && entryIdx == from.Entry {
+				continue
+			}
+			label := fmt.Sprintf("Entry %d - empty", entryIdx+1)
+			if !entry.CharacterData.Empty() {
+				label = fmt.Sprintf("Entry %d - %s (swap)", entryIdx+1, entry.CharacterData.Name)
+			}
+			options = append(options, label)
+			entries = append(entries, entryIdx)
+		}
+		entrySelect.SetOptions(options)
+		entrySelect.ClearSelected()
+	}
+	boxSelect.SetSelectedIndex(from.Box)
+
+	form := widget.NewForm(
+		widget.NewFormItem("Box", boxSelect),
+		widget.NewFormItem("Entry", entrySelect),
+	)
+	dialog.ShowCustomConfirm(title, "Move", "Cancel", form, func(confirm bool) {
+		if !confirm || entrySelect.SelectedIndex() < 0 {
+			return
+		}
+		onPicked(savedata.StorageSlot{Box: boxSelect.SelectedIndex(), Entry: entries[entrySelect.SelectedIndex()]})
+	}, myWindow)
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"pandorasbank/savedata"
+)
+
+// checkSaveOnLoad offers to review a newly opened save's problems. Findings
+// that are only informational don't interrupt.
+func checkSaveOnLoad(bankWindow *BankWindow) {
+	session := bankWindow.Session
+	if session == nil {
+		return
+	}
+
+	problems := 0
+	for _, finding := range savedata.Validate(session.GameSave, session.Bank) {
+		if finding.Severity >= savedata.SeverityWarning {
+			problems++
+		}
+	}
+	if problems == 0 {
+		return
+	}
+
+	dialog.ShowCustomConfirm("Save Check", "Review", "Ignore",
+		widget.NewLabel(fmt.Sprintf("Found %d possible problems in this save or the bank.", problems)),
+		func(review bool) {
+			if review {
+				showValidation(bankWindow)
+			}
+		}, bankWindow.Window)
+}
+
+// showValidation lists what savedata.Validate finds in the open save and
+// bank, with a button for each safe fix. It stays up to date as they change.
+func showValidation(bankWindow *BankWindow) {
+	if bankWindow.ValidationPanel != nil {
+		return
+	}
+
+	validationWindow := fyne.CurrentApp().NewWindow("Check Save")
+	bankWindow.ValidationPanel = container.NewVBox()
+	validationWindow.SetOnClosed(func() {
+		bankWindow.ValidationPanel = nil
+	})
+	refreshValidation(bankWindow)
+
+	validationWindow.SetContent(container.NewVScroll(bankWindow.ValidationPanel))
+	validationWindow.Resize(fyne.NewSize(600, 500))
+	validationWindow.Show()
+}
+
+func refreshValidation(bankWindow *BankWindow) {
+	panel := bankWindow.ValidationPanel
+	if panel == nil {
+		return
+	}
+	panel.RemoveAll()
+	defer panel.Refresh()
+
+	session := bankWindow.Session
+	if session == nil {
+		panel.Add(widget.NewLabel("No save is open."))
+		return
+	}
+
+	findings := savedata.Validate(session.GameSave, session.Bank)
+	if len(findings) == 0 {
+		panel.Add(widget.NewLabel("No problems found."))
+		return
+	}
+
+	fixable := savedata.Fixable(findings)
+	fixAllButton := widget.NewButton(fmt.Sprintf("Fix All (%d)", len(fixable)), func() {
+		fixed := savedata.FixAll(session.GameSave, session.Bank)
+		session.Commit(fmt.Sprintf("Fix %d save problems", len(fixed)))
+	})
+	if len(fixable) == 0 || session.ReadOnly() {
+		fixAllButton.Disable()
+	}
+	panel.Add(container.NewBorder(nil, nil, nil, fixAllButton,
+		widget.NewLabel(fmt.Sprintf("%d findings, %d with a fix", len(findings), len(fixable)))))
+	panel.Add(widget.NewSeparator())
+
+	for _, finding := range findings {
+		where := finding.Location
+		if finding.Subject != "" {
+			where += " " + finding.Subject
+		}
+
+		titleLabel := widget.NewLabel(fmt.Sprintf("[%s] %s", finding.Severity, where))
+		titleLabel.TextStyle = fyne.TextStyle{Bold: finding.Severity == savedata.SeverityError}
+		messageLabel := widget.NewLabel(finding.Message)
+		messageLabel.Wrapping = fyne.TextWrapWord
+
+		var fixButton fyne.CanvasObject
+		if finding.CanFix() {
+			button := widget.NewButton(finding.FixDescription, func() {
+				finding.Fix()
+				session.Commit(fmt.Sprintf("%s: %s", where, finding.FixDescription))
+			})
+			if session.ReadOnly() {
+				button.Disable()
+			}
+			fixButton = button
+		}
+
+		panel.Add(container.NewBorder(nil, nil, nil, fixButton, container.NewVBox(titleLabel, messageLabel)))
+	}
+}
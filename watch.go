@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"pandorasbank/savedata"
+)
+
+// fileSettleDelay is how long the save and bank have to stay untouched after
+// a change before they are reloaded, so a save is never read half written.
+const fileSettleDelay = 500 * time.Millisecond
+
+// watchOpenSave points the watcher at the open save and the bank, or at
+// nothing when no save is open.
+func watchOpenSave(bankWindow *BankWindow) {
+	if bankWindow.Watcher == nil {
+		return
+	}
+
+	var paths []string
+	if bankWindow.Session != nil {
+		paths = append(paths, bankWindow.Session.Path)
+		if bankPath, err := getBankFilePath(); err == nil {
+			paths = append(paths, bankPath)
+		}
+	}
+	if err := bankWindow.Watcher.Set(paths...); err != nil {
+		dialog.ShowError(fmt.Errorf("error watching the save for changes: %w", err), bankWindow.Window)
+	}
+}
+
+// readDiskState loads the save and bank as they are on disk, upgraded the same
+// way as when the save was opened so they compare equal if nothing changed.
+// If either is of a version that can't be written, unwritable says why; they
+// are still returned so they can be shown, as when a save is opened.
+func readDiskState(savePath string) (gameSave *savedata.GameSave, bank *savedata.Bank, unwritable error, err error) {
+	gameSave, err = savedata.LoadSave(savePath)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	_, saveErr := savedata.MigrateSave(gameSave)
+
+	bank, err = loadBank()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error loading bank: %w", err)
+	}
+	_, bankErr := savedata.MigrateBank(bank)
+
+	return gameSave, bank, errors.Join(saveErr, bankErr), nil
+}
+
+// checkExternalChanges reloads the open save and bank if something other
+// than us changed them. With staged edits pending it asks first.
+func checkExternalChanges(bankWindow *BankWindow) {
+	session := bankWindow.Session
+	if session == nil || bankWindow.conflictShown {
+		return
+	}
+
+	gameSave, bank, unwritable, err := readDiskState(session.Path)
+	if err != nil {
+		// Most likely caught mid-write; the watcher fires again once it's done.
+		return
+	}
+	if (unwritable == nil) != (session.Unwritable == nil) {
+		if unwritable != nil {
+			dialog.ShowError(unwritableError(unwritable), bankWindow.Window)
+		}
+		session.Unwritable = unwritable
+		session.changed()
+	}
+	saveData, bankData, err := marshalSessionState(gameSave, bank)
+	if err != nil || session.disk.sameState(historyEntry{Save: saveData, Bank: bankData}) {
+		return
+	}
+
+	if !session.HasPending() {
+		if err := session.Reload(gameSave, bank); err != nil {
+			dialog.ShowError(err, bankWindow.Window)
+		}
+		return
+	}
+
+	var diff savedata.SaveDiff
+	if diskSave, err := session.decodeSave(session.disk.Save); err == nil {
+		diff = savedata.Diff(diskSave, gameSave)
+	}
+	var diskBank savedata.Bank
+	if err := json.Unmarshal(session.disk.Bank, &diskBank); err == nil {
+		diff.Changes = append(diff.Changes, savedata.DiffBanks(&diskBank, bank).Changes...)
+	}
+
+	diffLabel := widget.NewLabel(diff.Text())
+	diffLabel.Wrapping = fyne.TextWrapWord
+	content := container.NewBorder(
+		widget.NewLabel(fmt.Sprintf("The save or bank was changed outside Pandora's Bank (most likely by the game)\nwhile you have %d pending changes. What changed on disk:", len(session.PendingActions()))),
+		nil, nil, nil,
+		container.NewVScroll(diffLabel),
+	)
+
+	bankWindow.conflictShown = true
+	conflictDialog := dialog.NewCustomConfirm("Save Changed on Disk", "Reload From Disk", "Keep My Changes", content, func(reload bool) {
+		bankWindow.conflictShown = false
+		if bankWindow.Session != session {
+			return
+		}
+
+		if reload {
+			err = session.Reload(gameSave, bank)
+		} else {
+			err = session.KeepPending(gameSave, bank)
+		}
+		if err != nil {
+			dialog.ShowError(err, bankWindow.Window)
+		}
+		// It may have changed again while we were asking.
+		checkExternalChanges(bankWindow)
+	}, bankWindow.Window)
+	conflictDialog.Resize(fyne.NewSize(600, 500))
+	conflictDialog.Show()
+}